@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mpy-yt/internal/mpv"
+	"mpy-yt/internal/proxy"
+	"mpy-yt/internal/youtube"
+	"os"
+)
+
+const (
+	exitError          = 1
+	exitUsage          = 2
+	exitNetwork        = 3
+	exitLoginRequired  = 4
+	exitAgeRestricted  = 5
+	exitMembersOnly    = 6
+	exitGeoBlocked     = 7
+	exitPrivate        = 8
+	exitRemoved        = 9
+	exitUpcoming       = 10
+	exitSchemaChanged  = 11
+	exitPlayerNotFound = 12
+	exitProxy          = 13
+)
+
+var exitClasses = []struct {
+	err  error
+	code int
+	hint string
+}{
+	{youtube.ErrPrivate, exitPrivate, "The uploader has made this video private."},
+	{youtube.ErrMembersOnly, exitMembersOnly, "This video requires a channel membership."},
+	{youtube.ErrAgeRestricted, exitAgeRestricted, "This video is age-restricted and needs a signed-in account."},
+	{youtube.ErrGeoBlocked, exitGeoBlocked, "This video is blocked in your region; try a proxy or VPN."},
+	{youtube.ErrRemoved, exitRemoved, "This video has been removed or does not exist."},
+	{youtube.ErrUpcoming, exitUpcoming, "This stream or premiere has not started yet; use --wait to play it when it starts."},
+	{youtube.ErrLoginRequired, exitLoginRequired, "YouTube requires sign-in; try a different client with --clients."},
+	{youtube.ErrNetwork, exitNetwork, "Check your internet connection."},
+	{youtube.ErrSchemaChanged, exitSchemaChanged, "YouTube changed its API; check for an update."},
+	{mpv.ErrPlayerNotFound, exitPlayerNotFound, "Install mpv and make sure it is on your PATH."},
+	{proxy.ErrListen, exitProxy, "The local streaming proxy could not bind to 127.0.0.1."},
+}
+
+const exitStatusHelp = `
+Exit status:
+  0   success
+  1   other error
+  2   invalid usage or identifier
+  3   network error
+  4   login required
+  5   age-restricted video
+  6   members-only video
+  7   video blocked in this country
+  8   private video
+  9   video removed or unavailable
+  10  stream or premiere has not started yet
+  11  unexpected YouTube API response
+  12  mpv not found
+  13  local proxy failed to start
+`
+
+func classify(err error) (int, string) {
+	for _, c := range exitClasses {
+		if errors.Is(err, c.err) {
+			return c.code, c.hint
+		}
+	}
+	return exitError, ""
+}
+
+func classifyCode(err error) int {
+	code, _ := classify(err)
+	return code
+}
+
+func fail(err error) {
+	code, hint := classify(err)
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if hint != "" {
+		fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+	}
+	os.Exit(code)
+}
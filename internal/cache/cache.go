@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"mpy-yt/internal/models"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const expiryMargin = 10 * time.Minute
+
+type Store struct {
+	dir string
+}
+
+func DefaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mpv-yt", "player")
+}
+
+// Key identifies one cached player response. Responses differ by client,
+// locale and whether the request was signed in, so all of them are part of
+// the key.
+type Key struct {
+	VideoId string
+	Client  string
+	Hl, Gl  string
+	Auth    bool
+}
+
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) path(key Key) string {
+	name := key.VideoId + "." + key.Client + "." + key.Hl + "-" + key.Gl
+	if key.Auth {
+		name += ".auth"
+	}
+	return filepath.Join(s.dir, name+".json")
+}
+
+func (s *Store) Get(key Key) (*models.PlayerData, bool) {
+	path := s.path(key)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var data models.PlayerData
+	if err := json.Unmarshal(raw, &data); err != nil || !valid(&data, time.Now()) {
+		os.Remove(path)
+		return nil, false
+	}
+	return &data, true
+}
+
+func (s *Store) Put(key Key, data *models.PlayerData) error {
+	if data.IsLive || data.IsUpcoming || key.Client == "" || Expiry(data).IsZero() {
+		return nil
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, key.VideoId+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+func (s *Store) Clean() (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || (!strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".tmp")) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func Expiry(data *models.PlayerData) time.Time {
+	var earliest time.Time
+	check := func(streamUrl string) {
+		u, err := url.Parse(streamUrl)
+		if err != nil {
+			return
+		}
+		secs, err := strconv.ParseInt(u.Query().Get("expire"), 10, 64)
+		if err != nil {
+			return
+		}
+		if t := time.Unix(secs, 0); earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+	for i := range data.Videos {
+		check(data.Videos[i].Url)
+	}
+	for i := range data.Audios {
+		check(data.Audios[i].Url)
+	}
+	for i := range data.Muxed {
+		check(data.Muxed[i].Url)
+	}
+	return earliest
+}
+
+func valid(data *models.PlayerData, now time.Time) bool {
+	expiry := Expiry(data)
+	return !expiry.IsZero() && now.Add(expiryMargin).Before(expiry)
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"fmt"
+	"mpy-yt/internal/models"
+	"testing"
+	"time"
+)
+
+func testData(expire time.Time) *models.PlayerData {
+	return &models.PlayerData{
+		Title:  "cached",
+		Client: "WEB",
+		Audios: []models.AudioStream{{Stream: models.Stream{Url: fmt.Sprintf("https://rr1.googlevideo.com/videoplayback?expire=%d&itag=140", expire.Unix())}}},
+	}
+}
+
+func TestStoreKey(t *testing.T) {
+	store := New(t.TempDir())
+	key := Key{VideoId: "dQw4w9WgXcQ", Client: "WEB", Hl: "en", Gl: "US"}
+	if err := store.Put(key, testData(time.Now().Add(6*time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := store.Get(key); !ok || data.Title != "cached" {
+		t.Fatalf("Get(%+v) = %+v, %v", key, data, ok)
+	}
+
+	others := []Key{
+		{VideoId: "9bZkp7q19f0", Client: "WEB", Hl: "en", Gl: "US"},
+		{VideoId: "dQw4w9WgXcQ", Client: "ANDROID", Hl: "en", Gl: "US"},
+		{VideoId: "dQw4w9WgXcQ", Client: "WEB", Hl: "de", Gl: "US"},
+		{VideoId: "dQw4w9WgXcQ", Client: "WEB", Hl: "en", Gl: "DE"},
+		{VideoId: "dQw4w9WgXcQ", Client: "WEB", Hl: "en", Gl: "US", Auth: true},
+	}
+	for _, other := range others {
+		if _, ok := store.Get(other); ok {
+			t.Errorf("Get(%+v) returned data stored under %+v", other, key)
+		}
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	store := New(t.TempDir())
+	key := Key{VideoId: "dQw4w9WgXcQ", Client: "WEB", Hl: "en", Gl: "US"}
+	if err := store.Put(key, testData(time.Now().Add(5*time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get(key); ok {
+		t.Error("Get returned data that expires within the margin")
+	}
+	if n, err := store.Clean(); err != nil || n != 0 {
+		t.Errorf("Clean removed %d files, %v; the expired entry should already be gone", n, err)
+	}
+
+	live := testData(time.Now().Add(6 * time.Hour))
+	live.IsLive = true
+	if err := store.Put(key, live); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get(key); ok {
+		t.Error("live stream data was cached")
+	}
+}
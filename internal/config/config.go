@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Config struct {
+	PoToken       string `json:"poToken"`
+	VisitorData   string `json:"visitorData"`
+	TokenProvider string `json:"tokenProvider"`
+	Cookies       string `json:"cookies"`
+	Hl            string `json:"hl"`
+	Gl            string `json:"gl"`
+}
+
+func DefaultFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mpv-yt", "config.json")
+}
+
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func EnvLocale() (hl, gl string) {
+	for _, key := range [...]string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		value, _, _ = strings.Cut(value, ".")
+		value, _, _ = strings.Cut(value, "@")
+		if value == "C" || value == "POSIX" {
+			return "", ""
+		}
+		lang, region, _ := strings.Cut(value, "_")
+		return strings.ToLower(lang), strings.ToUpper(region)
+	}
+	return "", ""
+}
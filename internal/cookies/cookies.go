@@ -0,0 +1,100 @@
+package cookies
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const httpOnlyPrefix = "#HttpOnly_"
+
+type Cookie struct {
+	http.Cookie
+	IncludeSubdomains bool
+}
+
+func Parse(r io.Reader) ([]Cookie, error) {
+	var cookies []Cookie
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimRight(sc.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(text, httpOnlyPrefix) {
+			text = text[len(httpOnlyPrefix):]
+			httpOnly = true
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab-separated fields, got %d", line, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry: %w", line, err)
+		}
+		c := Cookie{
+			Cookie: http.Cookie{
+				Domain:   fields[0],
+				Path:     fields[2],
+				Secure:   strings.EqualFold(fields[3], "TRUE"),
+				Name:     fields[5],
+				Value:    fields[6],
+				HttpOnly: httpOnly,
+			},
+			IncludeSubdomains: strings.EqualFold(fields[1], "TRUE"),
+		}
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, sc.Err()
+}
+
+func Load(path string) (http.CookieJar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	parsed, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cookies file %s: %w", path, err)
+	}
+	return NewJar(parsed)
+}
+
+func NewJar(parsed []Cookie) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, c := range parsed {
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			continue
+		}
+		host := strings.TrimPrefix(c.Domain, ".")
+		cookie := c.Cookie
+		cookie.Domain = ""
+		if c.IncludeSubdomains {
+			cookie.Domain = host
+		}
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: "/"}, []*http.Cookie{&cookie})
+	}
+	return jar, nil
+}
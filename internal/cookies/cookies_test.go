@@ -0,0 +1,102 @@
+package cookies
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = "# Netscape HTTP Cookie File\n" +
+	"\n" +
+	".youtube.com\tTRUE\t/\tTRUE\t4102444800\tSAPISID\tsapisid-value\n" +
+	"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t4102444800\tHSID\thsid-value\n" +
+	"www.youtube.com\tFALSE\t/\tFALSE\t0\tPREF\tf6=40000000\n" +
+	".youtube.com\tTRUE\t/\tTRUE\t946684800\tOLD\texpired\r\n"
+
+func TestParse(t *testing.T) {
+	cookies, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cookies) != 4 {
+		t.Fatalf("got %d cookies, want 4", len(cookies))
+	}
+
+	sapisid := cookies[0]
+	if sapisid.Name != "SAPISID" || sapisid.Value != "sapisid-value" || sapisid.Domain != ".youtube.com" {
+		t.Errorf("unexpected first cookie: %+v", sapisid)
+	}
+	if !sapisid.IncludeSubdomains || !sapisid.Secure || sapisid.HttpOnly {
+		t.Errorf("first cookie flags = subdomains %v secure %v httpOnly %v", sapisid.IncludeSubdomains, sapisid.Secure, sapisid.HttpOnly)
+	}
+	if !sapisid.Expires.Equal(time.Unix(4102444800, 0)) {
+		t.Errorf("first cookie expires %v", sapisid.Expires)
+	}
+
+	if hsid := cookies[1]; hsid.Name != "HSID" || !hsid.HttpOnly || hsid.Domain != ".youtube.com" {
+		t.Errorf("#HttpOnly_ line parsed as %+v", hsid)
+	}
+
+	pref := cookies[2]
+	if pref.IncludeSubdomains || pref.Secure || !pref.Expires.IsZero() {
+		t.Errorf("session cookie parsed as %+v", pref)
+	}
+
+	if old := cookies[3]; old.Value != "expired" {
+		t.Errorf("CRLF line parsed as %+v", old)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{
+		".youtube.com\tTRUE\t/\tTRUE\t0\tNAME\n",
+		".youtube.com\tTRUE\t/\tTRUE\tnever\tNAME\tvalue\n",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestNewJar(t *testing.T) {
+	cookies, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jar, err := NewJar(cookies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(rawUrl string) map[string]bool {
+		u, _ := url.Parse(rawUrl)
+		found := map[string]bool{}
+		for _, c := range jar.Cookies(u) {
+			found[c.Name] = true
+		}
+		return found
+	}
+
+	www := names("https://www.youtube.com/")
+	for _, name := range []string{"SAPISID", "HSID", "PREF"} {
+		if !www[name] {
+			t.Errorf("www.youtube.com is missing %s", name)
+		}
+	}
+	if www["OLD"] {
+		t.Error("expired cookie was added to the jar")
+	}
+
+	music := names("https://music.youtube.com/")
+	if !music["SAPISID"] || !music["HSID"] {
+		t.Errorf("subdomain cookies missing on music.youtube.com: %v", music)
+	}
+	if music["PREF"] {
+		t.Error("host-only cookie was sent to a subdomain")
+	}
+
+	if plain := names("http://www.youtube.com/"); plain["SAPISID"] || !plain["PREF"] {
+		t.Errorf("secure flag not honoured over http: %v", plain)
+	}
+}
@@ -1,26 +1,144 @@
 package models
 
+import "time"
+
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
 type Stream struct {
-	Url     string
-	Bitrate int64
-	Size    int64
+	Id         string
+	Url        string
+	MimeType   string
+	Bitrate    int64
+	Size       int64
+	Itag       int
+	InitRange  ByteRange
+	IndexRange ByteRange
 }
 
 type VideoStream struct {
 	Stream
-	Quality string
+	Quality        string
+	Codec          string
+	Container      string
+	Width          int
+	Height         int
+	Fps            int
+	Hdr            bool
+	ColorPrimaries string
+	ColorTransfer  string
 }
 
 type AudioStream struct {
 	Stream
-	Language  string
-	Name      string
-	IsDefault bool
+	Language   string
+	Name       string
+	IsDefault  bool
+	SampleRate int
+	Channels   int
+}
+
+type MuxedStream struct {
+	VideoStream
+}
+
+type Selection struct {
+	Video *VideoStream
+	Audio *AudioStream
+	Muxed *MuxedStream
+}
+
+type SubtitleTrack struct {
+	Language        string
+	Name            string
+	IsAutoGenerated bool
+	Url             string
+}
+
+type Chapter struct {
+	Title string
+	Start time.Duration
+}
+
+type Segment struct {
+	Category string
+	Label    string
+	Start    time.Duration
+	End      time.Duration
+}
+
+type MusicInfo struct {
+	Artist      string
+	Album       string
+	TrackNumber int
+	VideoType   string
+}
+
+type HeatMarker struct {
+	Start     time.Duration
+	Duration  time.Duration
+	Intensity float64
 }
 
 type PlayerData struct {
-	Title        string
-	ThumbnailUrl string
-	Videos       []VideoStream
-	Audios       []AudioStream
+	Title           string
+	Author          string
+	ChannelId       string
+	ViewCount       int64
+	Description     string
+	Keywords        []string
+	IsPrivate       bool
+	PublishDate     string
+	Category        string
+	ThumbnailUrl    string
+	Music           *MusicInfo
+	Videos          []VideoStream
+	Audios          []AudioStream
+	Muxed           []MuxedStream
+	Subtitles       []SubtitleTrack
+	Duration        time.Duration
+	Chapters        []Chapter
+	Heatmap         []HeatMarker
+	MostReplayed    []Segment
+	IsLive          bool
+	IsLiveDvr       bool
+	IsUpcoming      bool
+	ScheduledStart  time.Time
+	HlsManifestUrl  string
+	DashManifestUrl string
+	Client          string
+}
+
+type PlaylistEntry struct {
+	VideoId  string
+	Title    string
+	Channel  string
+	Duration time.Duration
+}
+
+type Playlist struct {
+	Id      string
+	Title   string
+	Entries []PlaylistEntry
+}
+
+type ItemKind int
+
+const (
+	ItemVideo ItemKind = iota
+	ItemPlaylist
+	ItemChannel
+)
+
+type ListItem struct {
+	Kind       ItemKind
+	Id         string
+	Title      string
+	Channel    string
+	Duration   string
+	Views      string
+	Published  string
+	VideoCount string
 }
@@ -0,0 +1,82 @@
+package mpv
+
+import (
+	"fmt"
+	"mpy-yt/internal/models"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var metaEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+func writeChapters(chapters []models.Chapter, duration time.Duration) (string, error) {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for i, c := range chapters {
+		end := duration
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Start
+		}
+		if end <= c.Start {
+			end = c.Start + time.Millisecond
+		}
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			c.Start.Milliseconds(), end.Milliseconds(), metaEscaper.Replace(c.Title))
+	}
+
+	f, err := os.CreateTemp("", "mpv-yt-*.ffmeta")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func markSegments(chapters []models.Chapter, segments []models.Segment, prefix, fallbackTitle string, duration time.Duration) []models.Chapter {
+	titleAt := func(t time.Duration) string {
+		title := fallbackTitle
+		for _, c := range chapters {
+			if c.Start > t {
+				break
+			}
+			title = c.Title
+		}
+		return title
+	}
+
+	merged := make([]models.Chapter, 0, len(chapters)+2*len(segments))
+	for _, c := range chapters {
+		inside := false
+		for _, s := range segments {
+			if c.Start > s.Start && c.Start < s.End {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			merged = append(merged, c)
+		}
+	}
+	for _, s := range segments {
+		merged = slices.DeleteFunc(merged, func(c models.Chapter) bool { return c.Start == s.Start })
+		merged = append(merged, models.Chapter{Title: prefix + s.Label, Start: s.Start})
+		if duration == 0 || s.End < duration-time.Second {
+			if !slices.ContainsFunc(merged, func(c models.Chapter) bool { return c.Start == s.End }) {
+				merged = append(merged, models.Chapter{Title: titleAt(s.End), Start: s.End})
+			}
+		}
+	}
+	slices.SortStableFunc(merged, func(a, b models.Chapter) int {
+		return int(a.Start - b.Start)
+	})
+	if len(merged) > 0 && merged[0].Start > 0 {
+		merged = slices.Insert(merged, 0, models.Chapter{Title: titleAt(0), Start: 0})
+	}
+	return merged
+}
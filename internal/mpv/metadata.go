@@ -0,0 +1,55 @@
+package mpv
+
+import (
+	"fmt"
+	"mpy-yt/internal/models"
+	"strings"
+	"time"
+)
+
+var expansionEscaper = strings.NewReplacer("$", "$$")
+
+func metadataArgs(data *models.PlayerData, audioOnly bool) []string {
+	title := data.Title
+	var details []string
+	if m := data.Music; audioOnly && m != nil && m.Artist != "" {
+		title = m.Artist + " – " + data.Title
+		if m.Album != "" {
+			details = append(details, m.Album)
+		}
+		if m.TrackNumber > 0 {
+			details = append(details, fmt.Sprintf("Track %d", m.TrackNumber))
+		}
+	} else if data.Author != "" {
+		details = append(details, data.Author)
+	}
+	if data.IsLive {
+		details = append(details, "LIVE")
+	} else if data.Duration > 0 {
+		details = append(details, clock(data.Duration))
+	}
+
+	windowTitle := title
+	if len(details) > 0 {
+		windowTitle += " — " + strings.Join(details, " · ")
+	}
+	osd := title
+	if len(details) > 0 {
+		osd += "\n" + strings.Join(details, " · ")
+	}
+
+	return []string{
+		"--title=" + expansionEscaper.Replace(windowTitle),
+		"--force-media-title=" + title,
+		"--osd-playing-msg=" + expansionEscaper.Replace(osd),
+		"--osd-playing-msg-duration=3000",
+	}
+}
+
+func clock(d time.Duration) string {
+	secs := int(d / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
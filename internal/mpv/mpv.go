@@ -1,39 +1,78 @@
 package mpv
 
 import (
+	"errors"
 	"fmt"
 	"mpy-yt/internal/models"
 	"mpy-yt/internal/proxy"
+	"os"
 	"os/exec"
+	"time"
 )
 
-func Launch(title, thumbUrl string, video *models.VideoStream, audio *models.AudioStream) error {
+var ErrPlayerNotFound = errors.New("mpv executable not found")
+
+type Options struct {
+	Subtitles    []models.SubtitleTrack
+	Segments     []models.Segment
+	SkipSegments bool
+	Dash         bool
+	AudioOnly    bool
+	Start        time.Duration
+	End          time.Duration
+	Resolver     proxy.Resolver
+}
+
+func Launch(data *models.PlayerData, sel models.Selection, opts Options) error {
+	thumbUrl := data.ThumbnailUrl
+	video := sel.Video
+	muxedAudio := sel.Muxed != nil && opts.AudioOnly
+	if sel.Muxed != nil && !muxedAudio {
+		video = &sel.Muxed.VideoStream
+	}
 	var vStream, aStream *models.Stream
 	if video != nil {
 		vStream = &video.Stream
 	}
-	if audio != nil {
-		aStream = &audio.Stream
+	if sel.Audio != nil {
+		aStream = &sel.Audio.Stream
+	} else if muxedAudio {
+		aStream = &sel.Muxed.Stream
 	}
 
-	srv, vUrl, aUrl, err := proxy.Start(vStream, aStream)
+	dash := opts.Dash && sel.Video != nil && sel.Audio != nil
+	hls := video == nil && aStream == nil && data.HlsManifestUrl != ""
+	var srv *proxy.Server
+	var vUrl, aUrl string
+	var err error
+	if dash {
+		srv, vUrl, err = proxy.StartDash(data)
+	} else if hls {
+		srv, vUrl, err = proxy.StartManifest(data.HlsManifestUrl)
+		aUrl = vUrl
+	} else {
+		srv, vUrl, aUrl, err = proxy.Start(vStream, aStream)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to start proxy: %w", err)
 	}
 	defer srv.Close()
+	srv.SetResolver(opts.Resolver)
 
 	var args []string
-	if video != nil {
-		args = []string{
-			"--title=" + title,
-			"--force-media-title= ",
+	if video != nil || hls && !opts.AudioOnly {
+		dumbMode := "yes"
+		if video != nil && video.Hdr {
+			dumbMode = "no"
+		}
+		args = append(metadataArgs(data, false),
 			"--keep-open=yes",
 			"--cache=yes",
 			"--demuxer-max-bytes=256MiB",
 			"--no-ytdl",
 			"--hwdec=auto",
 			"--profile=fast",
-			"--gpu-dumb-mode=yes",
+			"--gpu-dumb-mode="+dumbMode,
 			"--vd-lavc-skiploopfilter=nonref",
 			"--vd-lavc-threads=0",
 			"--sws-allow-zimg=no",
@@ -43,12 +82,22 @@ func Launch(title, thumbUrl string, video *models.VideoStream, audio *models.Aud
 			"--force-window=yes",
 			"--terminal=no",
 			vUrl,
-			"--audio-file=" + aUrl,
+		)
+		if dash {
+			args = append(args,
+				fmt.Sprintf("--vid=%d", srv.DashTrack(vStream)),
+				fmt.Sprintf("--aid=%d", srv.DashTrack(aStream)),
+			)
+		} else if sel.Muxed == nil && !hls {
+			args = append(args, "--audio-file="+aUrl)
+		}
+	} else if thumbUrl != "" && !hls {
+		// A muxed stream brings its own video track, so the thumbnail is the second one.
+		thumbTrack := "--vid=1"
+		if muxedAudio {
+			thumbTrack = "--vid=2"
 		}
-	} else if thumbUrl != "" {
-		args = []string{
-			"--title=" + title,
-			"--force-media-title= ",
+		args = append(metadataArgs(data, true),
 			"--keep-open=yes",
 			"--cache=yes",
 			"--demuxer-max-bytes=256MiB",
@@ -59,15 +108,13 @@ func Launch(title, thumbUrl string, video *models.VideoStream, audio *models.Aud
 			"--force-window=yes",
 			"--terminal=no",
 			aUrl,
-			"--external-file=" + thumbUrl,
-			"--vid=1",
+			"--external-file="+thumbUrl,
+			thumbTrack,
 			"--image-display-duration=inf",
 			"--video-unscaled=yes",
-		}
+		)
 	} else {
-		args = []string{
-			"--title=" + title,
-			"--force-media-title= ",
+		args = append(metadataArgs(data, true),
 			"--keep-open=yes",
 			"--cache=yes",
 			"--demuxer-max-bytes=256MiB",
@@ -77,15 +124,96 @@ func Launch(title, thumbUrl string, video *models.VideoStream, audio *models.Aud
 			"--vid=no",
 			aUrl,
 			"--force-window",
+		)
+	}
+
+	chapters := data.Chapters
+	if len(data.MostReplayed) > 0 {
+		chapters = markSegments(chapters, data.MostReplayed, "", data.Title, data.Duration)
+	}
+	if len(opts.Segments) > 0 {
+		chapters = markSegments(chapters, opts.Segments, "[SponsorBlock]: ", data.Title, data.Duration)
+		if opts.SkipSegments {
+			path, err := writeSkipScript(opts.Segments)
+			if err != nil {
+				return fmt.Errorf("failed to write skip script: %w", err)
+			}
+			defer os.Remove(path)
+			args = append(args, "--script="+path)
 		}
 	}
+	if len(chapters) > 0 {
+		path, err := writeChapters(chapters, data.Duration)
+		if err != nil {
+			return fmt.Errorf("failed to write chapters: %w", err)
+		}
+		defer os.Remove(path)
+		args = append(args, "--chapters-file="+path)
+	}
 
+	if opts.Start > 0 {
+		args = append(args, fmt.Sprintf("--start=%.3f", opts.Start.Seconds()))
+	}
+	if opts.End > 0 {
+		args = append(args, fmt.Sprintf("--end=%.3f", opts.End.Seconds()))
+	}
+
+	if video != nil || thumbUrl != "" || hls {
+		for _, sub := range opts.Subtitles {
+			args = append(args, "--sub-file="+srv.AddSubtitle(sub))
+		}
+	}
+
+	return run(args)
+}
+
+func LaunchLive(data *models.PlayerData, audioOnly, direct bool) error {
+	manifestUrl := data.HlsManifestUrl
+	if manifestUrl == "" {
+		manifestUrl = data.DashManifestUrl
+	}
+	if !direct {
+		srv, url, err := proxy.StartManifest(manifestUrl)
+		if err != nil {
+			return fmt.Errorf("failed to start proxy: %w", err)
+		}
+		defer srv.Close()
+		manifestUrl = url
+	}
+
+	args := append(metadataArgs(data, audioOnly),
+		"--keep-open=yes",
+		"--cache=yes",
+		"--demuxer-max-bytes=256MiB",
+		"--no-ytdl",
+		"--hwdec=auto",
+		"--force-window=yes",
+		"--terminal=no",
+	)
+	if data.IsLiveDvr {
+		args = append(args,
+			"--demuxer-seekable-cache=yes",
+			"--demuxer-max-back-bytes=1GiB",
+		)
+	}
+	if audioOnly {
+		args = append(args, "--vid=no")
+	}
+	args = append(args, manifestUrl)
+
+	return run(args)
+}
+
+func run(args []string) error {
 	cmd := exec.Command("mpv", args...)
 	cmd.Stdin = nil
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 
 	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%w: %w", ErrPlayerNotFound, err)
+		}
 		return fmt.Errorf("error launching mpv: %w", err)
 	}
 
@@ -0,0 +1,45 @@
+package mpv
+
+import (
+	"fmt"
+	"mpy-yt/internal/models"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const skipScript = `local segments = {
+%s}
+local skipped = {}
+mp.observe_property("time-pos", "number", function(_, pos)
+	if pos == nil then
+		return
+	end
+	for i, s in ipairs(segments) do
+		if not skipped[i] and pos >= s[1] and pos < s[2] - 0.25 then
+			skipped[i] = true
+			mp.set_property_number("time-pos", s[2])
+			mp.osd_message("Skipped " .. s[3])
+			return
+		end
+	end
+end)
+`
+
+func writeSkipScript(segments []models.Segment) (string, error) {
+	var b strings.Builder
+	for _, s := range segments {
+		fmt.Fprintf(&b, "\t{%.3f, %.3f, %s},\n", s.Start.Seconds(), s.End.Seconds(), strconv.Quote(s.Label))
+	}
+
+	f, err := os.CreateTemp("", "mpv-yt-*.lua")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, skipScript, b.String()); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mpy-yt/internal/models"
+	"net"
+	"slices"
+	"strings"
+)
+
+const audioChannelScheme = "urn:mpeg:dash:23003:3:audio_channel_configuration:2011"
+
+type mpd struct {
+	XMLName       xml.Name `xml:"MPD"`
+	Xmlns         string   `xml:"xmlns,attr"`
+	Profiles      string   `xml:"profiles,attr"`
+	Type          string   `xml:"type,attr"`
+	Duration      string   `xml:"mediaPresentationDuration,attr"`
+	MinBufferTime string   `xml:"minBufferTime,attr"`
+	Period        struct {
+		AdaptationSets []*adaptationSet `xml:"AdaptationSet"`
+	} `xml:"Period"`
+}
+
+type adaptationSet struct {
+	MimeType            string            `xml:"mimeType,attr"`
+	Lang                string            `xml:"lang,attr,omitempty"`
+	SubsegmentAlignment bool              `xml:"subsegmentAlignment,attr"`
+	Representations     []*representation `xml:"Representation"`
+}
+
+type representation struct {
+	Id          string       `xml:"id,attr"`
+	Codecs      string       `xml:"codecs,attr,omitempty"`
+	Bandwidth   int64        `xml:"bandwidth,attr"`
+	Width       int          `xml:"width,attr,omitempty"`
+	Height      int          `xml:"height,attr,omitempty"`
+	FrameRate   int          `xml:"frameRate,attr,omitempty"`
+	SampleRate  int          `xml:"audioSamplingRate,attr,omitempty"`
+	Channels    *descriptor  `xml:"AudioChannelConfiguration"`
+	BaseUrl     string       `xml:"BaseURL"`
+	SegmentBase *segmentBase `xml:"SegmentBase"`
+	stream      *models.Stream
+}
+
+type descriptor struct {
+	SchemeIdUri string `xml:"schemeIdUri,attr"`
+	Value       string `xml:"value,attr"`
+}
+
+type segmentBase struct {
+	IndexRange     string      `xml:"indexRange,attr"`
+	Initialization *byteRanged `xml:"Initialization"`
+}
+
+type byteRanged struct {
+	Range string `xml:"range,attr"`
+}
+
+func StartDash(data *models.PlayerData) (*Server, string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrListen, err)
+	}
+	s := &Server{listener: l}
+
+	m := mpd{
+		Xmlns:         "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:      "urn:mpeg:dash:profile:isoff-on-demand:2011",
+		Type:          "static",
+		Duration:      fmt.Sprintf("PT%.3fS", data.Duration.Seconds()),
+		MinBufferTime: "PT1.5S",
+	}
+	var videoSets, audioSets []*adaptationSet
+	for i := range data.Videos {
+		v := &data.Videos[i]
+		mime, codecs := splitMime(v.MimeType)
+		if mime == "" {
+			continue
+		}
+		rep := &representation{
+			Id:        fmt.Sprintf("v%d", i),
+			Codecs:    codecs,
+			Bandwidth: v.Bitrate,
+			Width:     v.Width,
+			Height:    v.Height,
+			FrameRate: v.Fps,
+		}
+		addRepresentation(&videoSets, mime, "", rep, &v.Stream)
+	}
+	for i := range data.Audios {
+		a := &data.Audios[i]
+		mime, codecs := splitMime(a.MimeType)
+		if mime == "" {
+			continue
+		}
+		rep := &representation{
+			Id:         fmt.Sprintf("a%d", i),
+			Codecs:     codecs,
+			Bandwidth:  a.Bitrate,
+			SampleRate: a.SampleRate,
+		}
+		if a.Channels > 0 {
+			rep.Channels = &descriptor{SchemeIdUri: audioChannelScheme, Value: fmt.Sprint(a.Channels)}
+		}
+		lang := a.Language
+		if lang == "und" {
+			lang = ""
+		}
+		addRepresentation(&audioSets, mime, lang, rep, &a.Stream)
+	}
+	if len(videoSets) == 0 {
+		l.Close()
+		return nil, "", errors.New("no streams with format metadata for a DASH manifest")
+	}
+
+	for _, set := range videoSets {
+		for _, rep := range set.Representations {
+			s.videos = append(s.videos, rep.stream)
+			rep.BaseUrl = fmt.Sprintf("%s/v/%d", s.base(), len(s.videos)-1)
+		}
+	}
+	for _, set := range audioSets {
+		for _, rep := range set.Representations {
+			s.audios = append(s.audios, rep.stream)
+			rep.BaseUrl = fmt.Sprintf("%s/a/%d", s.base(), len(s.audios)-1)
+		}
+	}
+
+	m.Period.AdaptationSets = append(videoSets, audioSets...)
+	out, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		l.Close()
+		return nil, "", err
+	}
+	s.dash = append([]byte(xml.Header), out...)
+
+	go s.serve()
+	return s, s.base() + "/manifest.mpd", nil
+}
+
+func (s *Server) DashTrack(stream *models.Stream) int {
+	for _, list := range [][]*models.Stream{s.videos, s.audios} {
+		if i := slices.Index(list, stream); i >= 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func addRepresentation(sets *[]*adaptationSet, mime, lang string, rep *representation, stream *models.Stream) {
+	rep.stream = stream
+	if r := stream.IndexRange; r.End > 0 {
+		rep.SegmentBase = &segmentBase{IndexRange: fmt.Sprintf("%d-%d", r.Start, r.End)}
+		if r := stream.InitRange; r.End > 0 {
+			rep.SegmentBase.Initialization = &byteRanged{Range: fmt.Sprintf("%d-%d", r.Start, r.End)}
+		}
+	}
+	for _, set := range *sets {
+		if set.MimeType == mime && set.Lang == lang {
+			set.Representations = append(set.Representations, rep)
+			return
+		}
+	}
+	*sets = append(*sets, &adaptationSet{
+		MimeType:            mime,
+		Lang:                lang,
+		SubsegmentAlignment: true,
+		Representations:     []*representation{rep},
+	})
+}
+
+func splitMime(mimeType string) (string, string) {
+	mime, params, _ := strings.Cut(mimeType, ";")
+	_, codecs, _ := strings.Cut(params, "codecs=")
+	return strings.TrimSpace(mime), strings.Trim(codecs, `" `)
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const maxManifestSize = 16 * 1024 * 1024
+
+var (
+	hlsUriAttr  = regexp.MustCompile(`URI="([^"]+)"`)
+	dashBaseUrl = regexp.MustCompile(`<BaseURL>([^<]+)</BaseURL>`)
+)
+
+func StartManifest(manifestUrl string) (*Server, string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrListen, err)
+	}
+	s := &Server{
+		listener: l,
+		manifest: manifestUrl,
+	}
+	go s.serve()
+	name := "live.m3u8"
+	if strings.Contains(manifestUrl, "/dash/") || strings.HasSuffix(manifestUrl, ".mpd") {
+		name = "live.mpd"
+	}
+	return s, fmt.Sprintf("%s/%s", s.base(), name), nil
+}
+
+func (s *Server) base() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (s *Server) relayUrl(target string) string {
+	ext := ""
+	if u, err := url.Parse(target); err == nil {
+		if e := path.Ext(u.Path); len(e) <= 5 {
+			ext = e
+		}
+	}
+	return s.base() + "/r/" + base64.RawURLEncoding.EncodeToString([]byte(target)) + ext
+}
+
+func (s *Server) serveRelay(w http.ResponseWriter, r *http.Request, target string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if isManifest(contentType, target) && resp.StatusCode == http.StatusOK {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		body = s.rewriteManifest(body)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.Write(body)
+		return
+	}
+
+	for _, h := range [...]string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges"} {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	bufPtr := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bufPtr)
+	io.CopyBuffer(w, resp.Body, *bufPtr)
+}
+
+func (s *Server) resolveRelay(p string) (string, bool) {
+	encoded, rest, _ := strings.Cut(strings.TrimPrefix(p, "/r/"), "/")
+	encoded, _, _ = strings.Cut(encoded, ".")
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	target := string(raw)
+	if u, err := url.Parse(target); err != nil || !isAllowedHost(u.Hostname()) {
+		return "", false
+	}
+	if rest != "" {
+		target = strings.TrimSuffix(target, "/") + "/" + rest
+	}
+	return target, true
+}
+
+func isAllowedHost(host string) bool {
+	return strings.HasSuffix(host, ".googlevideo.com") || strings.HasSuffix(host, ".youtube.com")
+}
+
+func isManifest(contentType, target string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.Contains(contentType, "mpegurl") ||
+		strings.Contains(contentType, "dash+xml") ||
+		strings.Contains(target, "/hls_variant/") ||
+		strings.Contains(target, "/hls_playlist/") ||
+		strings.Contains(target, "/dash/")
+}
+
+func (s *Server) rewriteManifest(body []byte) []byte {
+	if bytes.Contains(body[:min(len(body), 512)], []byte("<MPD")) {
+		return dashBaseUrl.ReplaceAllFunc(body, func(m []byte) []byte {
+			target := string(dashBaseUrl.FindSubmatch(m)[1])
+			if !strings.HasPrefix(target, "http") {
+				return m
+			}
+			return []byte("<BaseURL>" + s.relayUrl(strings.TrimSuffix(target, "/")) + "/</BaseURL>")
+		})
+	}
+
+	lines := strings.Split(string(body), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if trimmed[0] == '#' {
+			lines[i] = hlsUriAttr.ReplaceAllStringFunc(line, func(m string) string {
+				target := hlsUriAttr.FindStringSubmatch(m)[1]
+				if !strings.HasPrefix(target, "http") {
+					return m
+				}
+				return `URI="` + s.relayUrl(target) + `"`
+			})
+			continue
+		}
+		if strings.HasPrefix(trimmed, "http") {
+			lines[i] = s.relayUrl(trimmed)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mpy-yt/internal/models"
@@ -18,6 +19,16 @@ const (
 	dialTimeout = 10 * time.Second
 )
 
+var ErrListen = errors.New("failed to start local proxy")
+
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status: %d", e.Code)
+}
+
 var transport = &http.Transport{
 	Proxy: http.ProxyFromEnvironment,
 	DialContext: (&net.Dialer{
@@ -39,6 +50,10 @@ var client = &http.Client{
 	Transport: transport,
 }
 
+func SetCookies(jar http.CookieJar) {
+	client.Jar = jar
+}
+
 var bufPool = sync.Pool{
 	New: func() any {
 		b := make([]byte, 128*1024)
@@ -47,27 +62,32 @@ var bufPool = sync.Pool{
 }
 
 type Server struct {
-	listener net.Listener
-	video    *models.Stream
-	audio    *models.Stream
+	listener  net.Listener
+	videos    []*models.Stream
+	audios    []*models.Stream
+	manifest  string
+	dash      []byte
+	mu        sync.Mutex
+	resolver  Resolver
+	subtitles []models.SubtitleTrack
 }
 
 func Start(video, audio *models.Stream) (*Server, string, string, error) {
 	l, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
-		return nil, "", "", err
+		return nil, "", "", fmt.Errorf("%w: %w", ErrListen, err)
 	}
 	s := &Server{
 		listener: l,
-		video:    video,
-		audio:    audio,
+		videos:   []*models.Stream{video},
+		audios:   []*models.Stream{audio},
 	}
 	go func() {
 		if video != nil {
-			warmUp(video.Url)
+			warmUp(s.currentUrl(video))
 		}
 		if audio != nil {
-			warmUp(audio.Url)
+			warmUp(s.currentUrl(audio))
 		}
 	}()
 	go s.serve()
@@ -109,11 +129,29 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Pragma", "no-cache")
 	w.Header().Set("Expires", "0")
 	var stream *models.Stream
-	switch r.URL.Path {
-	case "/v":
-		stream = s.video
-	case "/a":
-		stream = s.audio
+	switch path := r.URL.Path; {
+	case path == "/v" || strings.HasPrefix(path, "/v/"):
+		stream = pick(s.videos, path[2:])
+	case path == "/a" || strings.HasPrefix(path, "/a/"):
+		stream = pick(s.audios, path[2:])
+	case path == "/manifest.mpd" && s.dash != nil:
+		w.Header().Set("Content-Type", "application/dash+xml")
+		w.Write(s.dash)
+		return
+	case (path == "/live.m3u8" || path == "/live.mpd") && s.manifest != "":
+		s.serveRelay(w, r, s.manifest)
+		return
+	case strings.HasPrefix(path, "/s/"):
+		s.serveSubtitle(w, r, path)
+		return
+	case strings.HasPrefix(path, "/r/") && s.manifest != "":
+		target, ok := s.resolveRelay(path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.serveRelay(w, r, target)
+		return
 	default:
 		http.NotFound(w, r)
 		return
@@ -122,78 +160,160 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
-	startByte := int64(0)
-	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
-		if strings.HasPrefix(rangeHeader, "bytes=") {
-			if ranges := strings.Split(rangeHeader[6:], "-"); len(ranges) > 0 {
-				if val, err := strconv.ParseInt(ranges[0], 10, 64); err == nil {
-					startByte = val
-				}
-			}
-		}
-	}
 	total := stream.Size
 	if total == 0 {
 		total = 100 * 1024 * 1024 * 1024
 	}
-	w.Header().Set("Content-Type", "video/mp4")
+	start, end := parseRange(r.Header.Get("Range"), total)
+	if start > end {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", total))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	contentType := "video/mp4"
+	if mime, _, _ := strings.Cut(stream.MimeType, ";"); mime != "" {
+		contentType = mime
+	}
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Accept-Ranges", "bytes")
-	w.Header().Set("Content-Length", strconv.FormatInt(total-startByte, 10))
-	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", startByte, total-1, total))
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, total))
 	w.WriteHeader(http.StatusPartialContent)
-	s.stream(r.Context(), w, stream.Url, startByte, total)
+	s.stream(r.Context(), w, stream, start, end+1)
+}
+
+func pick(streams []*models.Stream, suffix string) *models.Stream {
+	idx := 0
+	if suffix != "" {
+		var err error
+		if idx, err = strconv.Atoi(suffix[1:]); err != nil {
+			return nil
+		}
+	}
+	if idx < 0 || idx >= len(streams) {
+		return nil
+	}
+	return streams[idx]
+}
+
+func parseRange(header string, total int64) (int64, int64) {
+	start, end := int64(0), total-1
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return start, end
+	}
+	lo, hi, _ := strings.Cut(spec, "-")
+	if val, err := strconv.ParseInt(lo, 10, 64); err == nil {
+		start = val
+	}
+	if val, err := strconv.ParseInt(hi, 10, 64); err == nil && val < end {
+		end = val
+	}
+	return start, end
 }
 
 type result struct {
 	resp *http.Response
 	err  error
 	end  int64
+	url  string
 }
 
-func (s *Server) stream(ctx context.Context, w io.Writer, url string, start, total int64) {
+func (s *Server) stream(ctx context.Context, w io.Writer, st *models.Stream, start, total int64) {
 	offset := start
-	nextCh := make(chan result, 1)
-	fetch := func(off, size int64) {
-		end := off + size
-		if end > total {
-			end = total
-		}
+	fetch := func(off, size int64) <-chan result {
+		end := min(off+size, total)
+		ch := make(chan result, 1)
 		go func() {
-			r, e := fetchChunk(ctx, url, off, end)
-			select {
-			case nextCh <- result{resp: r, err: e, end: end}:
-			case <-ctx.Done():
-				if r != nil && r.Body != nil {
-					r.Body.Close()
-				}
+			url := s.currentUrl(st)
+			if expired(url) && s.refresh(st, url) == nil {
+				url = s.currentUrl(st)
 			}
+			r, e := fetchChunk(ctx, url, off, end)
+			ch <- result{resp: r, err: e, end: end, url: url}
 		}()
+		return ch
 	}
-	fetch(offset, chunkSize)
+	next := fetch(offset, chunkSize)
+	defer func() { go discard(next) }()
 	bufPtr := bufPool.Get().(*[]byte)
 	defer bufPool.Put(bufPtr)
 	buf := *bufPtr
+	refreshes := 0
 	for offset < total {
 		var res result
 		select {
-		case res = <-nextCh:
+		case res = <-next:
+			next = nil
 		case <-ctx.Done():
 			return
 		}
 		if res.err != nil {
-			return
+			if !refreshable(res.err) || refreshes >= maxRefreshes || s.refresh(st, res.url) != nil {
+				return
+			}
+			refreshes++
+			next = fetch(offset, chunkSize)
+			continue
 		}
 		if res.end < total {
-			fetch(res.end, chunkSize)
+			next = fetch(res.end, chunkSize)
 		}
-		n, err := io.CopyBuffer(w, res.resp.Body, buf)
+		n, readErr, writeErr := copyChunk(w, res.resp.Body, buf)
 		res.resp.Body.Close()
+		offset += n
+		if writeErr != nil || ctx.Err() != nil {
+			return
+		}
+		if readErr == nil && offset == res.end {
+			refreshes = 0
+			continue
+		}
+		// The upstream body broke off or ended early, typically because
+		// mpv paused long enough for the connection or URL to go stale.
+		// Drop the prefetched chunk and resume from the exact offset.
 		if n > 0 {
-			offset += n
+			refreshes = 0
 		}
-		if err != nil {
+		if refreshes >= maxRefreshes {
 			return
 		}
+		refreshes++
+		if next != nil {
+			go discard(next)
+		}
+		next = fetch(offset, chunkSize)
+	}
+}
+
+func copyChunk(w io.Writer, r io.Reader, buf []byte) (n int64, readErr, writeErr error) {
+	for {
+		nr, err := r.Read(buf)
+		if nr > 0 {
+			nw, err := w.Write(buf[:nr])
+			n += int64(nw)
+			if err != nil {
+				return n, nil, err
+			}
+			if nw < nr {
+				return n, nil, io.ErrShortWrite
+			}
+		}
+		if err == io.EOF {
+			return n, nil, nil
+		}
+		if err != nil {
+			return n, err, nil
+		}
+	}
+}
+
+func discard(ch <-chan result) {
+	if ch == nil {
+		return
+	}
+	if res := <-ch; res.resp != nil {
+		res.resp.Body.Close()
 	}
 }
 
@@ -222,7 +342,10 @@ func fetchChunk(ctx context.Context, url string, start, end int64) (*http.Respon
 		}
 		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 			resp.Body.Close()
-			lastErr = fmt.Errorf("status: %d", resp.StatusCode)
+			lastErr = &StatusError{Code: resp.StatusCode}
+			if refreshable(lastErr) {
+				return nil, lastErr
+			}
 			continue
 		}
 		return resp, nil
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"mpy-yt/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testSize = 2*chunkSize + chunkSize/2
+
+type reply struct {
+	status int
+	cut    int64
+	clean  bool
+}
+
+type upstream struct {
+	mu      sync.Mutex
+	content []byte
+	starts  map[string][]int64
+}
+
+func newUpstream(t *testing.T, serve func(path string, start int64) reply) (*httptest.Server, *upstream) {
+	t.Helper()
+	u := &upstream{content: make([]byte, testSize), starts: map[string][]int64{}}
+	for i := range u.content {
+		u.content[i] = byte(i % 251)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lo, hi, _ := strings.Cut(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "-")
+		start, _ := strconv.ParseInt(lo, 10, 64)
+		end, _ := strconv.ParseInt(hi, 10, 64)
+		u.mu.Lock()
+		u.starts[r.URL.Path] = append(u.starts[r.URL.Path], start)
+		u.mu.Unlock()
+
+		rep := serve(r.URL.Path, start)
+		if rep.status != 0 {
+			w.WriteHeader(rep.status)
+			return
+		}
+		body := u.content[start : end+1]
+		if !rep.clean {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		if rep.cut > 0 {
+			body = body[:rep.cut]
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, u
+}
+
+func (u *upstream) requests(path string) []int64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return slices.Clone(u.starts[path])
+}
+
+func TestStreamRefreshesAfterBrokenBody(t *testing.T) {
+	const cut = 3 * 1024 * 1024
+	srv, up := newUpstream(t, func(path string, start int64) reply {
+		switch {
+		case path == "/fresh":
+			return reply{}
+		case start == 0:
+			return reply{cut: cut}
+		}
+		return reply{status: http.StatusForbidden}
+	})
+
+	st := &models.Stream{Id: "140", Url: srv.URL + "/stale", Size: testSize}
+	s := &Server{audios: []*models.Stream{st}}
+	s.SetResolver(func() (*models.PlayerData, error) {
+		return &models.PlayerData{Audios: []models.AudioStream{{Stream: models.Stream{Id: "140", Url: srv.URL + "/fresh"}}}}, nil
+	})
+
+	var out bytes.Buffer
+	s.stream(context.Background(), &out, st, 0, testSize)
+	if !bytes.Equal(out.Bytes(), up.content) {
+		t.Fatalf("streamed %d bytes, want the %d byte stream intact", out.Len(), testSize)
+	}
+	want := []int64{cut, cut + chunkSize, cut + 2*chunkSize}
+	if got := up.requests("/fresh"); !slices.Equal(got, want) {
+		t.Errorf("refreshed URL requested from %v, want %v", got, want)
+	}
+}
+
+func TestStreamResumesAfterShortBody(t *testing.T) {
+	const short = 1024 * 1024
+	var mu sync.Mutex
+	truncated := false
+	srv, up := newUpstream(t, func(path string, start int64) reply {
+		mu.Lock()
+		defer mu.Unlock()
+		if start == chunkSize && !truncated {
+			truncated = true
+			return reply{cut: short, clean: true}
+		}
+		return reply{}
+	})
+
+	st := &models.Stream{Id: "140", Url: srv.URL + "/a", Size: testSize}
+	s := &Server{audios: []*models.Stream{st}}
+	var out bytes.Buffer
+	s.stream(context.Background(), &out, st, 0, testSize)
+	if !bytes.Equal(out.Bytes(), up.content) {
+		t.Fatalf("streamed %d bytes, want the %d byte stream intact", out.Len(), testSize)
+	}
+	if got := up.requests("/a"); !slices.Contains(got, chunkSize+short) {
+		t.Errorf("requests started at %v, want a resume at %d", got, chunkSize+short)
+	}
+}
+
+func TestStreamStopsOnWriteError(t *testing.T) {
+	srv, up := newUpstream(t, func(string, int64) reply { return reply{} })
+	st := &models.Stream{Id: "140", Url: srv.URL + "/a", Size: testSize}
+	s := &Server{audios: []*models.Stream{st}}
+	s.stream(context.Background(), failingWriter{}, st, 0, testSize)
+	if got := up.requests("/a"); len(got) > 2 {
+		t.Errorf("kept fetching after the client went away: %v", got)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, http.ErrHandlerTimeout }
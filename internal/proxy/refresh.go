@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"mpy-yt/internal/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	expiryMargin = 30 * time.Second
+	maxRefreshes = 3
+)
+
+type Resolver func() (*models.PlayerData, error)
+
+func (s *Server) SetResolver(r Resolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resolver = r
+}
+
+func (s *Server) currentUrl(stream *models.Stream) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return stream.Url
+}
+
+func (s *Server) refresh(stream *models.Stream, staleUrl string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stream.Url != staleUrl {
+		return nil
+	}
+	if s.resolver == nil {
+		return errors.New("stream URL expired and no resolver is set")
+	}
+	data, err := s.resolver()
+	if err != nil {
+		return fmt.Errorf("failed to refresh stream URLs: %w", err)
+	}
+	for _, list := range [][]*models.Stream{s.videos, s.audios} {
+		for _, st := range list {
+			if st == nil {
+				continue
+			}
+			if fresh := findStream(data, st.Id); fresh != nil {
+				st.Url = fresh.Url
+			}
+		}
+	}
+	if stream.Url == staleUrl {
+		return fmt.Errorf("stream %s is no longer available", stream.Id)
+	}
+	return nil
+}
+
+func findStream(data *models.PlayerData, id string) *models.Stream {
+	for i := range data.Videos {
+		if data.Videos[i].Id == id {
+			return &data.Videos[i].Stream
+		}
+	}
+	for i := range data.Audios {
+		if data.Audios[i].Id == id {
+			return &data.Audios[i].Stream
+		}
+	}
+	for i := range data.Muxed {
+		if data.Muxed[i].Id == id {
+			return &data.Muxed[i].Stream
+		}
+	}
+	return nil
+}
+
+func expired(streamUrl string) bool {
+	u, err := url.Parse(streamUrl)
+	if err != nil {
+		return false
+	}
+	secs, err := strconv.ParseInt(u.Query().Get("expire"), 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Now().Add(expiryMargin).After(time.Unix(secs, 0))
+}
+
+func refreshable(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && (se.Code == http.StatusForbidden || se.Code == http.StatusGone)
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mpy-yt/internal/models"
+	"mpy-yt/internal/subtitles"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxSubtitleSize = 32 * 1024 * 1024
+
+func (s *Server) AddSubtitle(track models.SubtitleTrack) string {
+	s.subtitles = append(s.subtitles, track)
+	name := url.PathEscape(strings.ReplaceAll(track.Name, "/", "-"))
+	return fmt.Sprintf("%s/s/%d/%s.%s.vtt", s.base(), len(s.subtitles)-1, name, url.PathEscape(track.Language))
+}
+
+func (s *Server) serveSubtitle(w http.ResponseWriter, r *http.Request, path string) {
+	idxStr, file, _ := strings.Cut(strings.TrimPrefix(path, "/s/"), "/")
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 || idx >= len(s.subtitles) {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", s.subtitles[idx].Url, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		http.Error(w, resp.Status, http.StatusBadGateway)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSubtitleSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	cues, err := subtitles.Parse(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if strings.HasSuffix(file, ".srt") {
+		w.Header().Set("Content-Type", "application/x-subrip; charset=utf-8")
+		subtitles.WriteSrt(w, cues)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vtt; charset=utf-8")
+	subtitles.WriteVtt(w, cues)
+}
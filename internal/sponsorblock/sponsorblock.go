@@ -0,0 +1,105 @@
+package sponsorblock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mpy-yt/internal/models"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const DefaultApiUrl = "https://sponsor.ajay.app"
+
+var DefaultCategories = []string{"sponsor", "selfpromo", "intro", "outro", "music_offtopic"}
+
+var categoryLabels = map[string]string{
+	"sponsor":        "Sponsor",
+	"selfpromo":      "Self-promotion",
+	"interaction":    "Interaction reminder",
+	"intro":          "Intro",
+	"outro":          "Outro",
+	"preview":        "Preview",
+	"music_offtopic": "Non-music section",
+	"filler":         "Filler",
+}
+
+var httpClient = &http.Client{
+	Timeout: 5 * time.Second,
+}
+
+type apiVideo struct {
+	VideoId  string `json:"videoID"`
+	Segments []struct {
+		Category   string    `json:"category"`
+		ActionType string    `json:"actionType"`
+		Segment    []float64 `json:"segment"`
+	} `json:"segments"`
+}
+
+func GetSegments(apiUrl, videoId string, categories []string) ([]models.Segment, error) {
+	hash := sha256.Sum256([]byte(videoId))
+	cats, err := json.Marshal(categories)
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Set("categories", string(cats))
+	q.Set("actionType", "skip")
+	endpoint := strings.TrimSuffix(apiUrl, "/") + "/api/skipSegments/" + hex.EncodeToString(hash[:])[:4] + "?" + q.Encode()
+
+	resp, err := httpClient.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sponsorblock request failed: %d", resp.StatusCode)
+	}
+
+	var videos []apiVideo
+	if err := json.NewDecoder(resp.Body).Decode(&videos); err != nil {
+		return nil, err
+	}
+
+	var segments []models.Segment
+	for _, v := range videos {
+		if v.VideoId != videoId {
+			continue
+		}
+		for _, s := range v.Segments {
+			if len(s.Segment) != 2 || s.Segment[1] <= s.Segment[0] || !slices.Contains(categories, s.Category) {
+				continue
+			}
+			if s.ActionType != "" && s.ActionType != "skip" {
+				continue
+			}
+			label := categoryLabels[s.Category]
+			if label == "" {
+				label = s.Category
+			}
+			segments = append(segments, models.Segment{
+				Category: s.Category,
+				Label:    label,
+				Start:    seconds(s.Segment[0]),
+				End:      seconds(s.Segment[1]),
+			})
+		}
+	}
+	slices.SortFunc(segments, func(a, b models.Segment) int {
+		return int(a.Start - b.Start)
+	})
+	return segments, nil
+}
+
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
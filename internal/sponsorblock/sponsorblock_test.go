@@ -0,0 +1,95 @@
+package sponsorblock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func fakeServer(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv.URL + "/"
+}
+
+func TestGetSegments(t *testing.T) {
+	var path string
+	var categories []string
+	var actionType string
+	api := fakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.Unmarshal([]byte(r.URL.Query().Get("categories")), &categories)
+		actionType = r.URL.Query().Get("actionType")
+		w.Write([]byte(`[
+			{"videoID": "other123456", "segments": [{"category": "sponsor", "actionType": "skip", "segment": [1, 2]}]},
+			{"videoID": "dQw4w9WgXcQ", "segments": [
+				{"category": "intro", "actionType": "skip", "segment": [40, 50.5]},
+				{"category": "sponsor", "actionType": "skip", "segment": [10, 20]},
+				{"category": "sponsor", "actionType": "mute", "segment": [60, 70]},
+				{"category": "filler", "actionType": "skip", "segment": [80, 90]},
+				{"category": "sponsor", "segment": [100, 95]},
+				{"category": "sponsor", "segment": [120, 130]}
+			]}
+		]`))
+	})
+
+	segments, err := GetSegments(api, "dQw4w9WgXcQ", []string{"sponsor", "intro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/api/skipSegments/5f6b" {
+		t.Errorf("requested %s, want the 4-character hash prefix path", path)
+	}
+	if !slices.Equal(categories, []string{"sponsor", "intro"}) || actionType != "skip" {
+		t.Errorf("query categories = %v, actionType = %q", categories, actionType)
+	}
+
+	type span struct {
+		category   string
+		start, end time.Duration
+	}
+	var got []span
+	for _, s := range segments {
+		got = append(got, span{s.Category, s.Start, s.End})
+	}
+	want := []span{
+		{"sponsor", 10 * time.Second, 20 * time.Second},
+		{"intro", 40 * time.Second, 50500 * time.Millisecond},
+		{"sponsor", 120 * time.Second, 130 * time.Second},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("segments = %+v, want %+v", got, want)
+	}
+	if segments[0].Label != "Sponsor" {
+		t.Errorf("label = %q, want Sponsor", segments[0].Label)
+	}
+}
+
+func TestGetSegmentsNotFound(t *testing.T) {
+	api := fakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	segments, err := GetSegments(api, "dQw4w9WgXcQ", DefaultCategories)
+	if err != nil || segments != nil {
+		t.Errorf("GetSegments = %v, %v; want no segments and no error", segments, err)
+	}
+}
+
+func TestGetSegmentsErrors(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"server error": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+		"invalid json": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>"))
+		},
+	} {
+		if _, err := GetSegments(fakeServer(t, handler), "dQw4w9WgXcQ", DefaultCategories); err == nil {
+			t.Errorf("%s: GetSegments succeeded", name)
+		}
+	}
+}
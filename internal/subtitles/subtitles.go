@@ -0,0 +1,158 @@
+package subtitles
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+type json3Doc struct {
+	Events []struct {
+		TStartMs    int64 `json:"tStartMs"`
+		DDurationMs int64 `json:"dDurationMs"`
+		Segs        []struct {
+			Utf8 string `json:"utf8"`
+		} `json:"segs"`
+	} `json:"events"`
+}
+
+func Parse(data []byte) ([]Cue, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, errors.New("empty subtitle document")
+	}
+	switch trimmed[0] {
+	case '{':
+		return ParseJson3(trimmed)
+	case '<':
+		return ParseSrv3(trimmed)
+	}
+	return nil, errors.New("unknown subtitle format")
+}
+
+func ParseJson3(data []byte) ([]Cue, error) {
+	var doc json3Doc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	cues := make([]Cue, 0, len(doc.Events))
+	for _, ev := range doc.Events {
+		if len(ev.Segs) == 0 {
+			continue
+		}
+		var b strings.Builder
+		for _, seg := range ev.Segs {
+			b.WriteString(seg.Utf8)
+		}
+		cues = appendCue(cues, ev.TStartMs, ev.DDurationMs, b.String())
+	}
+	return finish(cues), nil
+}
+
+func ParseSrv3(data []byte) ([]Cue, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	cues := make([]Cue, 0, 64)
+	var (
+		inP        bool
+		start, dur int64
+		b          strings.Builder
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				inP = true
+				start, dur = 0, 0
+				b.Reset()
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "t":
+						start, _ = strconv.ParseInt(a.Value, 10, 64)
+					case "d":
+						dur, _ = strconv.ParseInt(a.Value, 10, 64)
+					}
+				}
+			case "br":
+				if inP {
+					b.WriteByte('\n')
+				}
+			}
+		case xml.CharData:
+			if inP {
+				b.Write(t)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" && inP {
+				inP = false
+				cues = appendCue(cues, start, dur, b.String())
+			}
+		}
+	}
+	return finish(cues), nil
+}
+
+func appendCue(cues []Cue, startMs, durMs int64, text string) []Cue {
+	text = strings.TrimSpace(text)
+	if text == "" || durMs <= 0 {
+		return cues
+	}
+	return append(cues, Cue{
+		Start: time.Duration(startMs) * time.Millisecond,
+		End:   time.Duration(startMs+durMs) * time.Millisecond,
+		Text:  text,
+	})
+}
+
+func finish(cues []Cue) []Cue {
+	for i := 0; i+1 < len(cues); i++ {
+		if next := cues[i+1].Start; next > cues[i].Start && next < cues[i].End {
+			cues[i].End = next
+		}
+	}
+	return cues
+}
+
+func WriteVtt(w io.Writer, cues []Cue) error {
+	var b bytes.Buffer
+	b.WriteString("WEBVTT\n\n")
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	for _, c := range cues {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", timestamp(c.Start, '.'), timestamp(c.End, '.'), r.Replace(c.Text))
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func WriteSrt(w io.Writer, cues []Cue) error {
+	var b bytes.Buffer
+	for i, c := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.Start, ','), timestamp(c.End, ','), c.Text)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func timestamp(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,85 @@
+package subtitles
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, name string) []Cue {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cues, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return cues
+}
+
+func checkCues(t *testing.T, got, want []Cue) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d cues, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cue %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseJson3(t *testing.T) {
+	checkCues(t, load(t, "captions.json3"), []Cue{
+		{Start: time.Second, End: 3 * time.Second, Text: "Never gonna give you up"},
+		{Start: 3 * time.Second, End: 5500 * time.Millisecond, Text: "Never gonna let you down"},
+		{Start: 7 * time.Second, End: 8500 * time.Millisecond, Text: "Tom & Jerry <3"},
+	})
+}
+
+func TestParseSrv3(t *testing.T) {
+	checkCues(t, load(t, "captions.srv3"), []Cue{
+		{Start: time.Second, End: 3 * time.Second, Text: "Never gonna\ngive you up"},
+		{Start: 3 * time.Second, End: 5500 * time.Millisecond, Text: "Never gonna let you down"},
+		{Start: 7 * time.Second, End: 8500 * time.Millisecond, Text: "Tom & Jerry <3"},
+	})
+}
+
+func TestParseUnknown(t *testing.T) {
+	for _, input := range []string{"", "   ", "WEBVTT\n"} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestWriteVtt(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteVtt(&b, []Cue{
+		{Start: time.Second, End: 3 * time.Second, Text: "Never gonna\ngive you up"},
+		{Start: time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond, End: time.Hour + 2*time.Minute + 5*time.Second, Text: "Tom & Jerry <3>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n" +
+		"00:00:01.000 --> 00:00:03.000\nNever gonna\ngive you up\n\n" +
+		"01:02:03.045 --> 01:02:05.000\nTom &amp; Jerry &lt;3&gt;\n\n"
+	if got := b.String(); got != want {
+		t.Errorf("WriteVtt =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteSrt(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteSrt(&b, []Cue{{Start: 1500 * time.Millisecond, End: 2 * time.Second, Text: "a & b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "1\n00:00:01,500 --> 00:00:02,000\na & b\n\n"; got != want {
+		t.Errorf("WriteSrt = %q, want %q", got, want)
+	}
+}
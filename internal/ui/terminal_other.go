@@ -0,0 +1,5 @@
+//go:build !windows
+
+package ui
+
+const terminalDevice = "/dev/tty"
@@ -2,19 +2,39 @@ package ui
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"mpy-yt/internal/models"
 	"mpy-yt/internal/youtube"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var stdin = bufio.NewScanner(os.Stdin)
 
+var Heatmap bool
+
+const (
+	maxKeywords         = 8
+	maxDescriptionLines = 3
+	heatmapWidth        = 60
+)
+
+func ReopenTerminal() error {
+	f, err := os.Open(terminalDevice)
+	if err != nil {
+		return err
+	}
+	stdin = bufio.NewScanner(f)
+	return nil
+}
+
 func GetIdentifierFromInput() string {
 	if clip := getClipboard(); clip != "" && len(clip) < 2048 {
-		if youtube.ExtractVideoId(clip) != "" {
+		if _, err := youtube.Parse(clip); err == nil {
 			return clip
 		}
 	}
@@ -25,75 +45,345 @@ func GetIdentifierFromInput() string {
 	return ""
 }
 
-func GetStreamSelection(data *models.PlayerData, qualityPref, langPref string, audioOnly bool) (*models.VideoStream, *models.AudioStream) {
-	if audioOnly {
-		return nil, selectAudio(data.Audios, langPref)
+func ShowInfo(data *models.PlayerData) {
+	showInfo(data, false)
+}
+
+func showInfo(data *models.PlayerData, audioOnly bool) {
+	fmt.Print("\033[H\033[2J")
+	var details []string
+	if m := data.Music; audioOnly && m != nil && m.Artist != "" {
+		fmt.Println(m.Artist + " – " + data.Title)
+		if m.Album != "" {
+			details = append(details, m.Album)
+		}
+		if m.TrackNumber > 0 {
+			details = append(details, fmt.Sprintf("Track %d", m.TrackNumber))
+		}
+	} else {
+		fmt.Println(data.Title)
+		if data.Author != "" {
+			details = append(details, data.Author)
+		}
 	}
-	if len(data.Videos) == 0 {
-		return nil, selectAudio(data.Audios, langPref)
+	if data.Duration > 0 {
+		details = append(details, formatDuration(data.Duration))
 	}
-	video := selectVideo(data.Videos, qualityPref)
-	if video == nil {
-		return nil, nil
+	if data.ViewCount > 0 {
+		details = append(details, formatCount(data.ViewCount)+" views")
 	}
-	if qualityPref == "" && langPref == "" {
-		fmt.Print("\033[H\033[2J")
-		fmt.Println(data.Title)
+	if data.PublishDate != "" {
+		details = append(details, data.PublishDate)
+	}
+	if data.Category != "" {
+		details = append(details, data.Category)
+	}
+	if data.IsPrivate {
+		details = append(details, "Private")
+	}
+	if len(details) > 0 {
+		fmt.Println(strings.Join(details, " · "))
+	}
+	if Heatmap && len(data.Heatmap) > 0 {
+		fmt.Println(sparkline(data.Heatmap, heatmapWidth))
+		if len(data.MostReplayed) > 0 {
+			peaks := make([]string, len(data.MostReplayed))
+			for i, p := range data.MostReplayed {
+				peaks[i] = formatDuration(p.Start)
+			}
+			fmt.Printf("Most replayed: %s\n", strings.Join(peaks, ", "))
+		}
+	}
+	if len(data.Keywords) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(data.Keywords[:min(len(data.Keywords), maxKeywords)], ", "))
+	}
+	if desc := strings.TrimSpace(data.Description); desc != "" {
+		lines := strings.Split(desc, "\n")
 		fmt.Println()
-		fmt.Printf("Video Quality: %s\n", video.Quality)
+		for _, line := range lines[:min(len(lines), maxDescriptionLines)] {
+			fmt.Println(line)
+		}
+		if len(lines) > maxDescriptionLines {
+			fmt.Println("...")
+		}
+	}
+	fmt.Println()
+}
+
+func sparkline(markers []models.HeatMarker, width int) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	width = min(width, len(markers))
+	var b strings.Builder
+	for col := range width {
+		from, to := col*len(markers)/width, (col+1)*len(markers)/width
+		peak := 0.0
+		for _, m := range markers[from:to] {
+			peak = max(peak, m.Intensity)
+		}
+		level := int(peak * float64(len(blocks)-1))
+		b.WriteRune(blocks[max(0, min(level, len(blocks)-1))])
+	}
+	return b.String()
+}
+
+func formatDuration(d time.Duration) string {
+	secs := int(d / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+func formatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
 	}
-	return video, selectAudio(data.Audios, langPref)
+	return b.String()
 }
 
-func selectVideo(videos []models.VideoStream, qualityPref string) *models.VideoStream {
-	if qualityPref != "" {
+func ShowLive(data *models.PlayerData) {
+	ShowInfo(data)
+	if data.IsLiveDvr {
+		fmt.Println("Live stream (DVR)")
+	} else {
+		fmt.Println("Live stream")
+	}
+}
+
+func ShowCountdown(data *models.PlayerData, remaining time.Duration) {
+	switch {
+	case data.ScheduledStart.IsZero():
+		fmt.Print("\r\033[KWaiting for the stream to start...")
+	case remaining > 0:
+		fmt.Printf("\r\033[KStarts in %s (%s)", formatDuration(remaining.Round(time.Second)), data.ScheduledStart.Local().Format("Mon 15:04"))
+	default:
+		fmt.Printf("\r\033[KScheduled for %s, waiting for the stream to go live...", data.ScheduledStart.Local().Format("Mon 15:04"))
+	}
+}
+
+type Preferences struct {
+	Quality         string
+	Language        string
+	DefaultLanguage string
+	AudioOnly       bool
+	Codecs          []string
+	Fps             int
+	NoHdr           bool
+}
+
+func GetStreamSelection(data *models.PlayerData, prefs Preferences) models.Selection {
+	interactive := prefs.Quality == "" && prefs.Language == ""
+	if interactive {
+		showInfo(data, prefs.AudioOnly)
+	}
+	muxed := make([]*models.VideoStream, len(data.Muxed))
+	for i := range data.Muxed {
+		muxed[i] = &data.Muxed[i].VideoStream
+	}
+	if len(data.Audios) == 0 {
+		return selectMuxed(data, muxed, prefs, interactive)
+	}
+	if prefs.AudioOnly || len(data.Videos) == 0 {
+		return models.Selection{Audio: selectAudio(data.Audios, prefs.Language, prefs.DefaultLanguage)}
+	}
+	videos := make([]*models.VideoStream, len(data.Videos))
+	for i := range data.Videos {
+		videos[i] = &data.Videos[i]
+	}
+	if !interactive {
+		muxed = nil
+	}
+	video := selectVideo(videos, muxed, prefs)
+	if video == nil {
+		return models.Selection{}
+	}
+	if interactive {
+		ShowInfo(data)
+		fmt.Printf("Video Quality: %s\n", videoLabel(video))
+	}
+	if m := findMuxed(data.Muxed, video); m != nil {
+		return models.Selection{Muxed: m}
+	}
+	return models.Selection{Video: video, Audio: selectAudio(data.Audios, prefs.Language, prefs.DefaultLanguage)}
+}
+
+func selectMuxed(data *models.PlayerData, muxed []*models.VideoStream, prefs Preferences, interactive bool) models.Selection {
+	if len(muxed) == 0 {
+		return models.Selection{}
+	}
+	if prefs.AudioOnly {
+		smallest := &data.Muxed[0]
+		for i := range data.Muxed {
+			if data.Muxed[i].Height < smallest.Height {
+				smallest = &data.Muxed[i]
+			}
+		}
+		fmt.Println("No audio-only stream is available, playing the muxed stream without video.")
+		return models.Selection{Muxed: smallest}
+	}
+	video := selectVideo(nil, muxed, prefs)
+	if video == nil {
+		return models.Selection{}
+	}
+	if interactive {
+		ShowInfo(data)
+		fmt.Printf("Video Quality: %s (muxed)\n", videoLabel(video))
+	}
+	return models.Selection{Muxed: findMuxed(data.Muxed, video)}
+}
+
+func findMuxed(muxed []models.MuxedStream, video *models.VideoStream) *models.MuxedStream {
+	for i := range muxed {
+		if &muxed[i].VideoStream == video {
+			return &muxed[i]
+		}
+	}
+	return nil
+}
+
+func filterHdr(videos []*models.VideoStream, noHdr bool) []*models.VideoStream {
+	if !noHdr {
+		return videos
+	}
+	sdr := make([]*models.VideoStream, 0, len(videos))
+	for _, v := range videos {
+		if !v.Hdr {
+			sdr = append(sdr, v)
+		}
+	}
+	if len(sdr) == 0 {
+		return videos
+	}
+	return sdr
+}
+
+func selectVideo(videos, muxed []*models.VideoStream, prefs Preferences) *models.VideoStream {
+	videos, muxed = filterHdr(videos, prefs.NoHdr), filterHdr(muxed, prefs.NoHdr)
+	candidates := videos
+	if len(candidates) == 0 {
+		candidates = muxed
+	}
+
+	if qualityPref := prefs.Quality; qualityPref != "" {
 		if strings.EqualFold(qualityPref, "highest") {
-			return &videos[0]
+			return bestVideo(candidates, parseQuality(candidates[0].Quality), prefs)
 		}
 		if strings.EqualFold(qualityPref, "lowest") {
-			return &videos[len(videos)-1]
+			return bestVideo(candidates, parseQuality(candidates[len(candidates)-1].Quality), prefs)
 		}
-		for i := range videos {
-			if strings.EqualFold(videos[i].Quality, qualityPref) {
-				return &videos[i]
+		if prefs.Fps == 0 {
+			if _, fps, ok := strings.Cut(strings.ToLower(qualityPref), "p"); ok {
+				prefs.Fps, _ = strconv.Atoi(fps)
 			}
 		}
 		reqQuality := parseQuality(qualityPref)
 		if reqQuality == -1 {
-			return &videos[0]
+			return bestVideo(candidates, parseQuality(candidates[0].Quality), prefs)
 		}
-		bestIdx, minDiff := 0, 1<<30
-		for i := range videos {
-			q := parseQuality(videos[i].Quality)
+		closest, minDiff := reqQuality, 1<<30
+		for _, v := range candidates {
+			q := parseQuality(v.Quality)
 			diff := q - reqQuality
 			if diff < 0 {
 				diff = -diff
 			}
 			if diff < minDiff {
 				minDiff = diff
-				bestIdx = i
+				closest = q
 			}
 		}
-		return &videos[bestIdx]
+		return bestVideo(candidates, closest, prefs)
+	}
+
+	defaultVideo := bestVideo(candidates, parseQuality(candidates[0].Quality), prefs)
+	options := candidates
+	if len(videos) > 0 {
+		options = append(slices.Clip(videos), muxed...)
 	}
+	defaultIdx := 0
 	fmt.Println("Video Quality")
-	for i, v := range videos {
-		fmt.Printf("  %d) %s\n", i+1, v.Quality)
+	for i, v := range options {
+		if v == defaultVideo {
+			defaultIdx = i
+		}
+		label := videoLabel(v)
+		if len(videos) == 0 || i >= len(videos) {
+			label += "  (muxed)"
+		}
+		fmt.Printf("  %d) %s\n", i+1, label)
 	}
-	fmt.Print("> Select video [1]: ")
+	fmt.Printf("> Select video [%d]: ", defaultIdx+1)
 	if stdin.Scan() {
 		line := strings.TrimSpace(stdin.Text())
 		if line == "" {
-			return &videos[0]
+			return defaultVideo
 		}
-		if choice, err := strconv.Atoi(line); err == nil && choice >= 1 && choice <= len(videos) {
-			return &videos[choice-1]
+		if choice, err := strconv.Atoi(line); err == nil && choice >= 1 && choice <= len(options) {
+			return options[choice-1]
 		}
 	}
 	os.Stderr.WriteString("Invalid selection.\n")
 	return nil
 }
 
+func bestVideo(candidates []*models.VideoStream, quality int, prefs Preferences) *models.VideoStream {
+	var best *models.VideoStream
+	for _, v := range candidates {
+		if parseQuality(v.Quality) != quality {
+			continue
+		}
+		if best == nil || betterVideo(v, best, prefs) {
+			best = v
+		}
+	}
+	if best == nil {
+		return candidates[0]
+	}
+	return best
+}
+
+func betterVideo(a, b *models.VideoStream, prefs Preferences) bool {
+	if ra, rb := codecRank(a.Codec, prefs.Codecs), codecRank(b.Codec, prefs.Codecs); ra != rb {
+		return ra < rb
+	}
+	if a.Fps != b.Fps {
+		if prefs.Fps > 0 {
+			da, db := a.Fps-prefs.Fps, b.Fps-prefs.Fps
+			return da*da < db*db
+		}
+		return a.Fps > b.Fps
+	}
+	if a.Hdr != b.Hdr {
+		return !a.Hdr
+	}
+	return a.Bitrate > b.Bitrate
+}
+
+func codecRank(codec string, prefs []string) int {
+	for i, p := range prefs {
+		if strings.EqualFold(strings.TrimSpace(p), codec) {
+			return i
+		}
+	}
+	return len(prefs)
+}
+
+func videoLabel(v *models.VideoStream) string {
+	res := v.Quality
+	if v.Fps > 30 {
+		res += strconv.Itoa(v.Fps)
+	}
+	if v.Hdr {
+		res += " HDR"
+	}
+	return fmt.Sprintf("%-11s %-5s %-5s %.1f Mbps", res, v.Codec, v.Container, float64(v.Bitrate)/1e6)
+}
+
 func parseQuality(q string) int {
 	v := 0
 	hasDigit := false
@@ -102,6 +392,8 @@ func parseQuality(q string) int {
 		if b >= '0' && b <= '9' {
 			v = v*10 + int(b-'0')
 			hasDigit = true
+		} else if hasDigit {
+			break
 		}
 	}
 	if !hasDigit {
@@ -110,21 +402,32 @@ func parseQuality(q string) int {
 	return v
 }
 
-func selectAudio(audios []models.AudioStream, langPref string) *models.AudioStream {
-	if len(audios) == 1 {
-		return &audios[0]
-	}
-	defaultIdx := 0
+func defaultAudio(audios []models.AudioStream, locale string) int {
+	base, _, _ := strings.Cut(locale, "-")
+	baseIdx, defaultIdx := -1, 0
 	for i := range audios {
-		if audios[i].IsDefault {
-			defaultIdx = i
-			break
-		}
 		lang := audios[i].Language
-		if len(lang) >= 2 && (lang[0]|32) == 'e' && (lang[1]|32) == 'n' {
+		if locale != "" && strings.EqualFold(lang, locale) {
+			return i
+		}
+		if prefix, _, _ := strings.Cut(lang, "-"); base != "" && baseIdx < 0 && strings.EqualFold(prefix, base) {
+			baseIdx = i
+		}
+		if audios[i].IsDefault && !audios[defaultIdx].IsDefault {
 			defaultIdx = i
 		}
 	}
+	if baseIdx >= 0 {
+		return baseIdx
+	}
+	return defaultIdx
+}
+
+func selectAudio(audios []models.AudioStream, langPref, defaultLang string) *models.AudioStream {
+	if len(audios) == 1 {
+		return &audios[0]
+	}
+	defaultIdx := defaultAudio(audios, defaultLang)
 	if langPref != "" {
 		for i := range audios {
 			if strings.EqualFold(audios[i].Language, langPref) {
@@ -168,3 +471,123 @@ func selectAudio(audios []models.AudioStream, langPref string) *models.AudioStre
 	os.Stderr.WriteString("Invalid selection.\n")
 	return nil
 }
+
+func PickItems(items []models.ListItem, shown int, hasMore bool) ([]int, bool) {
+	for i := shown; i < len(items); i++ {
+		it := &items[i]
+		title := it.Title
+		switch it.Kind {
+		case models.ItemPlaylist:
+			title = "[playlist] " + title
+		case models.ItemChannel:
+			title = "[channel] " + title
+		}
+		fmt.Printf("%3d) %s\n", i+1, title)
+		details := make([]string, 0, 4)
+		for _, d := range [...]string{it.Channel, it.Duration, it.Views, it.Published} {
+			if d != "" {
+				details = append(details, d)
+			}
+		}
+		if it.VideoCount != "" {
+			details = append(details, it.VideoCount+" videos")
+		}
+		if len(details) > 0 {
+			fmt.Printf("     %s\n", strings.Join(details, " · "))
+		}
+	}
+	if len(items) == 0 {
+		fmt.Println("No results.")
+	}
+	prompt := "> Select items (e.g. 1,3-5)"
+	if hasMore {
+		prompt += ", n for more"
+	}
+	fmt.Print(prompt + ", q to quit: ")
+	for stdin.Scan() {
+		line := strings.TrimSpace(stdin.Text())
+		switch {
+		case line == "" || strings.EqualFold(line, "q"):
+			return nil, false
+		case strings.EqualFold(line, "n") && hasMore:
+			return nil, true
+		}
+		if indices, err := ParseSelection(line, len(items)); err == nil {
+			return indices, false
+		}
+		fmt.Print("Invalid selection. " + prompt + ": ")
+	}
+	return nil, false
+}
+
+func ParseSelection(spec string, n int) ([]int, error) {
+	var indices []int
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil || start < 1 {
+			return nil, errors.New("invalid item: " + part)
+		}
+		end := start
+		if isRange {
+			end = n
+			if hi != "" {
+				if end, err = strconv.Atoi(hi); err != nil || end < start {
+					return nil, errors.New("invalid range: " + part)
+				}
+			}
+		}
+		for i := start; i <= end && i <= n; i++ {
+			indices = append(indices, i-1)
+		}
+	}
+	if len(indices) == 0 {
+		return nil, errors.New("selection matches no items")
+	}
+	return indices, nil
+}
+
+func SelectSubtitles(tracks []models.SubtitleTrack, langs []string, allowAuto bool) []models.SubtitleTrack {
+	var selected []models.SubtitleTrack
+	for _, lang := range langs {
+		lang = strings.TrimSpace(lang)
+		if lang == "" {
+			continue
+		}
+		if strings.EqualFold(lang, "all") {
+			for _, t := range tracks {
+				if !t.IsAutoGenerated {
+					selected = append(selected, t)
+				}
+			}
+			continue
+		}
+		if t := findSubtitle(tracks, lang, false); t != nil {
+			selected = append(selected, *t)
+		} else if allowAuto {
+			if t := findSubtitle(tracks, lang, true); t != nil {
+				selected = append(selected, *t)
+			}
+		}
+	}
+	return selected
+}
+
+func findSubtitle(tracks []models.SubtitleTrack, lang string, auto bool) *models.SubtitleTrack {
+	for i := range tracks {
+		if tracks[i].IsAutoGenerated == auto && strings.EqualFold(tracks[i].Language, lang) {
+			return &tracks[i]
+		}
+	}
+	for i := range tracks {
+		code := tracks[i].Language
+		if tracks[i].IsAutoGenerated == auto && len(code) > len(lang) && code[len(lang)] == '-' && strings.EqualFold(code[:len(lang)], lang) {
+			return &tracks[i]
+		}
+	}
+	return nil
+}
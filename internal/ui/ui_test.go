@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"mpy-yt/internal/models"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		spec string
+		n    int
+		want []int
+		err  bool
+	}{
+		{"1", 5, []int{0}, false},
+		{"1,3", 5, []int{0, 2}, false},
+		{"2-4", 5, []int{1, 2, 3}, false},
+		{"4-", 5, []int{3, 4}, false},
+		{"1-3,5", 5, []int{0, 1, 2, 4}, false},
+		{" 2 , 4 ", 5, []int{1, 3}, false},
+		{"3-10", 5, []int{2, 3, 4}, false},
+		{"7", 5, nil, true},
+		{"0", 5, nil, true},
+		{"4-2", 5, nil, true},
+		{"a", 5, nil, true},
+		{"", 5, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSelection(tt.spec, tt.n)
+		if (err != nil) != tt.err || !slices.Equal(got, tt.want) {
+			t.Errorf("ParseSelection(%q, %d) = %v, %v; want %v, error %v", tt.spec, tt.n, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestGetStreamSelectionAudioOnlyMuxed(t *testing.T) {
+	muxed := func(height int) models.MuxedStream {
+		return models.MuxedStream{VideoStream: models.VideoStream{Height: height, Quality: strconv.Itoa(height) + "p"}}
+	}
+	data := &models.PlayerData{Muxed: []models.MuxedStream{muxed(720), muxed(360), muxed(480)}}
+
+	sel := GetStreamSelection(data, Preferences{Quality: "best", AudioOnly: true})
+	if sel.Muxed != &data.Muxed[1] {
+		t.Errorf("audio-only selection = %+v, want the smallest muxed stream", sel.Muxed)
+	}
+	if sel.Video != nil || sel.Audio != nil {
+		t.Errorf("audio-only selection has separate streams: %+v", sel)
+	}
+
+	sel = GetStreamSelection(data, Preferences{Quality: "best"})
+	if sel.Muxed != &data.Muxed[0] {
+		t.Errorf("video selection = %+v, want the 720p muxed stream", sel.Muxed)
+	}
+}
+
+func TestDefaultAudio(t *testing.T) {
+	audios := []models.AudioStream{
+		{Language: "en-US", IsDefault: true},
+		{Language: "de-DE"},
+		{Language: "es-419"},
+		{Language: "es-ES"},
+	}
+	tests := []struct {
+		locale string
+		want   int
+	}{
+		{"de", 1},
+		{"de-AT", 1},
+		{"es-ES", 3},
+		{"es", 2},
+		{"fr", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := defaultAudio(audios, tt.locale); got != tt.want {
+			t.Errorf("defaultAudio(%q) = %d, want %d", tt.locale, got, tt.want)
+		}
+	}
+
+	original := []models.AudioStream{{Language: "ja"}, {Language: "en", IsDefault: true}}
+	if got := defaultAudio(original, "fr"); got != 1 {
+		t.Errorf("without a locale match defaultAudio = %d, want the default track", got)
+	}
+	if got := selectAudio(audios, "pt", "de"); got != &audios[1] {
+		t.Errorf("unmatched --lang picked %+v, want the locale's track", got)
+	}
+}
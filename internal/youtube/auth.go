@@ -0,0 +1,67 @@
+package youtube
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const authOrigin = "https://www.youtube.com"
+
+var cookieJar http.CookieJar
+
+var authChain = []string{"WEB_SAFARI", "WEB"}
+
+func SetCookies(jar http.CookieJar) {
+	cookieJar = jar
+}
+
+func activeChain() []string {
+	if cookieJar == nil {
+		return clientChain
+	}
+	chain := slices.Clone(clientChain)
+	for _, key := range authChain {
+		if !slices.Contains(chain, key) {
+			chain = append(chain, key)
+		}
+	}
+	return chain
+}
+
+// authOnly reports whether key is only in the chain because cookies are set.
+// Those clients return ciphered formats, so they are a last resort for
+// videos that need a signed-in user.
+func authOnly(key string) bool {
+	return cookieJar != nil && slices.Contains(authChain, key) && !slices.Contains(clientChain, key)
+}
+
+func authorize(req *http.Request) {
+	if cookieJar == nil {
+		return
+	}
+	origin, _ := url.Parse(authOrigin)
+	var sapisid string
+	for _, c := range cookieJar.Cookies(origin) {
+		req.AddCookie(c)
+		if c.Name == "SAPISID" || (sapisid == "" && c.Name == "__Secure-3PAPISID") {
+			sapisid = c.Value
+		}
+	}
+	if sapisid == "" {
+		return
+	}
+	req.Header.Set("Authorization", sapisidHash(sapisid, authOrigin, time.Now()))
+	req.Header.Set("X-Origin", authOrigin)
+	req.Header.Set("Origin", authOrigin)
+	req.Header.Set("X-Goog-AuthUser", "0")
+}
+
+func sapisidHash(sapisid, origin string, now time.Time) string {
+	ts := now.Unix()
+	sum := sha1.Sum(fmt.Appendf(nil, "%d %s %s", ts, sapisid, origin))
+	return fmt.Sprintf("SAPISIDHASH %d_%x", ts, sum)
+}
@@ -0,0 +1,125 @@
+package youtube
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSapisidHash(t *testing.T) {
+	got := sapisidHash("AbCdEf/123", "https://www.youtube.com", time.Unix(1700000000, 0))
+	want := "SAPISIDHASH 1700000000_eaddb74604d89ec3d0294853c7cf4f491ff7e767"
+	if got != want {
+		t.Errorf("sapisidHash = %q, want %q", got, want)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	jar, _ := cookiejar.New(nil)
+	u, _ := url.Parse(authOrigin)
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "SAPISID", Value: "secret", Domain: "youtube.com", Path: "/"},
+		{Name: "SID", Value: "sid", Domain: "youtube.com", Path: "/"},
+	})
+	SetCookies(jar)
+	defer SetCookies(nil)
+
+	req, _ := http.NewRequest(http.MethodPost, apiBase+"player", nil)
+	authorize(req)
+	if auth := req.Header.Get("Authorization"); !strings.HasPrefix(auth, "SAPISIDHASH ") {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if req.Header.Get("Origin") != authOrigin || req.Header.Get("X-Origin") != authOrigin {
+		t.Errorf("origin headers not set: %v", req.Header)
+	}
+	if c, err := req.Cookie("SID"); err != nil || c.Value != "sid" {
+		t.Errorf("SID cookie not sent: %v", err)
+	}
+}
+
+func TestActiveChain(t *testing.T) {
+	if got := activeChain(); !slices.Equal(got, clientChain) {
+		t.Errorf("without cookies activeChain = %v, want %v", got, clientChain)
+	}
+
+	jar, _ := cookiejar.New(nil)
+	SetCookies(jar)
+	defer SetCookies(nil)
+	want := append(slices.Clone(clientChain), authChain...)
+	if got := activeChain(); !slices.Equal(got, want) {
+		t.Errorf("with cookies activeChain = %v, want %v", got, want)
+	}
+}
+
+func TestResolvePlayerDataAuthFallback(t *testing.T) {
+	jar, _ := cookiejar.New(nil)
+	SetCookies(jar)
+	defer SetCookies(nil)
+
+	var anonymous []string
+	for _, key := range clientChain {
+		anonymous = append(anonymous, clients[key].name)
+	}
+	playable := fixture(t, "player_unknown_itags.json")
+	hlsOnly := fixture(t, "player_hls_only.json")
+	refusal := func(status, reason string) any {
+		return map[string]any{"playabilityStatus": map[string]any{"status": status, "reason": reason}}
+	}
+	tests := []struct {
+		name      string
+		anonymous any
+		want      []string
+		client    string
+	}{
+		{"public", playable, anonymous[:1], clientChain[0]},
+		{"age restricted", refusal("LOGIN_REQUIRED", "Sign in to confirm your age"), append(slices.Clone(anonymous), "WEB"), "WEB_SAFARI"},
+		{"members only", refusal("UNPLAYABLE", "Join this channel to get access to members-only content like this video"), append(slices.Clone(anonymous), "WEB"), "WEB_SAFARI"},
+		{"unplayable", refusal("UNPLAYABLE", "Something else"), anonymous, ""},
+	}
+	for _, tt := range tests {
+		var requested []string
+		fakeApi(t, func(req apiRequest) any {
+			name := str(req.Body, "context", "client", "clientName")
+			requested = append(requested, name)
+			if name == "WEB" {
+				return hlsOnly
+			}
+			return tt.anonymous
+		})
+		data, err := resolvePlayerData("dQw4w9WgXcQ")
+		if !slices.Equal(requested, tt.want) {
+			t.Errorf("%s: requested clients %v, want %v", tt.name, requested, tt.want)
+		}
+		if tt.client == "" {
+			if err == nil {
+				t.Errorf("%s: resolved with %s, want an error", tt.name, data.Client)
+			}
+			continue
+		}
+		if err != nil || data.Client != tt.client {
+			t.Errorf("%s: resolved %+v, %v; want client %s", tt.name, data, err, tt.client)
+		}
+	}
+}
+
+func TestFetchPlayerDataHlsFallback(t *testing.T) {
+	player := fixture(t, "player_hls_only.json")
+	fakeApi(t, func(req apiRequest) any {
+		return player
+	})
+
+	data, err := fetchPlayerData("dQw4w9WgXcQ", clients["WEB_SAFARI"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.HlsManifestUrl == "" {
+		t.Fatal("HLS manifest was not used for a response with only ciphered formats")
+	}
+	if len(data.Videos) != 0 || len(data.Audios) != 0 || data.IsLive {
+		t.Errorf("unexpected streams: %d videos, %d audios, live %v", len(data.Videos), len(data.Audios), data.IsLive)
+	}
+}
@@ -0,0 +1,64 @@
+package youtube
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var channelTabs = map[string]string{
+	"videos":    "EgZ2aWRlb3PyBgQKAjoA",
+	"shorts":    "EgZzaG9ydHPyBgUKA5oBAA==",
+	"live":      "EgdzdHJlYW1z8gYECgJ6AA==",
+	"playlists": "EglwbGF5bGlzdHPyBgQKAkIA",
+}
+
+func ExtractChannel(input string) string {
+	t, err := Parse(input)
+	if err != nil {
+		return ""
+	}
+	return t.Channel
+}
+
+func isChannelId(s string) bool {
+	return len(s) == 24 && strings.HasPrefix(s, "UC") && isValidPlaylistId(s)
+}
+
+func resolveChannelId(ref string) (string, error) {
+	if isChannelId(ref) {
+		return ref, nil
+	}
+	var resp any
+	payload := map[string]any{"url": "https://www.youtube.com/" + ref}
+	if err := callApi("navigation/resolve_url", clientWeb, payload, &resp); err != nil {
+		return "", err
+	}
+	id := str(resp, "endpoint", "browseEndpoint", "browseId")
+	if !isChannelId(id) {
+		return "", errors.New("channel not found: " + ref)
+	}
+	return id, nil
+}
+
+func BrowseChannel(ref, tab string) (*Page, error) {
+	params, ok := channelTabs[strings.ToLower(tab)]
+	if !ok {
+		return nil, errors.New("unknown channel tab: " + tab)
+	}
+	id, err := resolveChannelId(ref)
+	if err != nil {
+		return nil, err
+	}
+	var resp any
+	payload := map[string]any{"browseId": id, "params": url.QueryEscape(params)}
+	if err := callApi("browse", clientWeb, payload, &resp); err != nil {
+		return nil, err
+	}
+	if msg := alertMessage(resp); msg != "" {
+		return nil, errors.New(msg)
+	}
+	page := parsePage("browse", dig(resp, "contents"))
+	page.Title = str(resp, "metadata", "channelMetadataRenderer", "title")
+	return page, nil
+}
@@ -0,0 +1,111 @@
+package youtube
+
+import (
+	"mpy-yt/internal/models"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	leadingTimestamp  = regexp.MustCompile(`^[^\w(]*\(?((?:\d{1,2}:)?\d{1,2}:\d{2})\)?\s*[-–—:|.]*\s*(.+)$`)
+	trailingTimestamp = regexp.MustCompile(`^(.+?)\s*[-–—:|]*\s*\(?((?:\d{1,2}:)?\d{1,2}:\d{2})\)?$`)
+)
+
+func fetchWatchNext(videoId string) any {
+	var resp any
+	if err := callApi("next", clientWeb, map[string]any{"videoId": videoId}, &resp); err != nil {
+		return nil
+	}
+	return resp
+}
+
+func parseChapters(next any) []models.Chapter {
+	var chapters []models.Chapter
+	walk(next, func(key string, val any) bool {
+		if len(chapters) > 0 {
+			return false
+		}
+		if key != "markersMap" {
+			return true
+		}
+		entries, _ := val.([]any)
+		for _, e := range entries {
+			ch := markerChapters(dig(e, "value", "chapters"))
+			if len(ch) > 0 && (len(chapters) == 0 || str(e, "key") == "DESCRIPTION_CHAPTERS") {
+				chapters = ch
+			}
+		}
+		return false
+	})
+	if len(chapters) > 0 {
+		return chapters
+	}
+
+	walk(next, func(key string, val any) bool {
+		if key != "macroMarkersListItemRenderer" {
+			return true
+		}
+		chapters = append(chapters, models.Chapter{
+			Title: text(dig(val, "title")),
+			Start: parseClock(text(dig(val, "timeDescription"))),
+		})
+		return false
+	})
+	if !validChapters(chapters, 0) {
+		return nil
+	}
+	return chapters
+}
+
+func markerChapters(node any) []models.Chapter {
+	items, _ := node.([]any)
+	chapters := make([]models.Chapter, 0, len(items))
+	for _, item := range items {
+		r := dig(item, "chapterRenderer")
+		ms, ok := dig(r, "timeRangeStartMillis").(float64)
+		if !ok {
+			continue
+		}
+		chapters = append(chapters, models.Chapter{
+			Title: text(dig(r, "title")),
+			Start: time.Duration(ms) * time.Millisecond,
+		})
+	}
+	return chapters
+}
+
+func parseDescriptionChapters(desc string, duration time.Duration) []models.Chapter {
+	var chapters []models.Chapter
+	for line := range strings.Lines(desc) {
+		line = strings.TrimSpace(line)
+		var ts, title string
+		if m := leadingTimestamp.FindStringSubmatch(line); m != nil {
+			ts, title = m[1], m[2]
+		} else if m := trailingTimestamp.FindStringSubmatch(line); m != nil {
+			ts, title = m[2], m[1]
+		} else {
+			continue
+		}
+		chapters = append(chapters, models.Chapter{
+			Title: strings.TrimSpace(title),
+			Start: parseClock(ts),
+		})
+	}
+	if len(chapters) < 3 || !validChapters(chapters, duration) {
+		return nil
+	}
+	return chapters
+}
+
+func validChapters(chapters []models.Chapter, duration time.Duration) bool {
+	if len(chapters) == 0 || chapters[0].Start != 0 {
+		return false
+	}
+	for i := 1; i < len(chapters); i++ {
+		if chapters[i].Start <= chapters[i-1].Start {
+			return false
+		}
+	}
+	return duration == 0 || chapters[len(chapters)-1].Start < duration
+}
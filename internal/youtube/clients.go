@@ -0,0 +1,151 @@
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type clientConfig struct {
+	key         string
+	name        string
+	version     string
+	id          string
+	deviceModel string
+	userAgent   string
+	embedded    bool
+	auth        bool
+	apiBase     *string
+	hl          string
+}
+
+type clientFileEntry struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Id          string `json:"id"`
+	DeviceModel string `json:"deviceModel"`
+	UserAgent   string `json:"userAgent"`
+	Embedded    bool   `json:"embedded"`
+	Auth        bool   `json:"auth"`
+}
+
+type clientFile struct {
+	Chain   []string          `json:"chain"`
+	Clients []clientFileEntry `json:"clients"`
+}
+
+var clients = map[string]clientConfig{
+	"ANDROID": {
+		key:       "ANDROID",
+		name:      "ANDROID",
+		version:   "19.50.42",
+		id:        "3",
+		userAgent: "com.google.android.youtube/19.50.42 (Linux; U; Android 11) gzip",
+	},
+	"IOS": {
+		key:         "IOS",
+		name:        "IOS",
+		version:     "21.03.2",
+		id:          "5",
+		deviceModel: "iPhone14,3",
+		userAgent:   "com.google.ios.youtube/21.03.2 (iPhone14,3; U; CPU iOS 18_2 like Mac OS X)",
+	},
+	"TV_EMBEDDED": {
+		key:      "TV_EMBEDDED",
+		name:     "TVHTML5_SIMPLY_EMBEDDED_PLAYER",
+		version:  "2.0",
+		id:       "85",
+		embedded: true,
+	},
+	"ANDROID_VR": {
+		key:         "ANDROID_VR",
+		name:        "ANDROID_VR",
+		version:     "1.62.27",
+		id:          "28",
+		deviceModel: "Quest 3",
+		userAgent:   "com.google.android.apps.youtube.vr.oculus/1.62.27 (Linux; U; Android 12L; eureka-user Build/SQ3A.220605.009.A1) gzip",
+	},
+	"WEB_EMBEDDED": {
+		key:      "WEB_EMBEDDED",
+		name:     "WEB_EMBEDDED_PLAYER",
+		version:  "1.20250310.01.00",
+		id:       "56",
+		embedded: true,
+		auth:     true,
+	},
+	"WEB_SAFARI": {
+		key:       "WEB_SAFARI",
+		name:      "WEB",
+		version:   clientWeb.version,
+		id:        "1",
+		userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.5 Safari/605.1.15,gzip(gfe)",
+		auth:      true,
+	},
+	"WEB":       clientWeb,
+	"WEB_REMIX": clientMusic,
+}
+
+var clientChain = []string{"ANDROID", "IOS", "TV_EMBEDDED", "ANDROID_VR", "WEB_EMBEDDED"}
+
+func DefaultClientsFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mpv-yt", "clients.json")
+}
+
+func LoadClients(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f clientFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return fmt.Errorf("invalid clients file %s: %w", path, err)
+	}
+	for _, c := range f.Clients {
+		key := strings.ToUpper(c.Key)
+		if key == "" || c.Name == "" || c.Version == "" || c.Id == "" {
+			return fmt.Errorf("invalid clients file %s: client entries need key, name, version and id", path)
+		}
+		clients[key] = clientConfig{
+			key:         key,
+			name:        c.Name,
+			version:     c.Version,
+			id:          c.Id,
+			deviceModel: c.DeviceModel,
+			userAgent:   c.UserAgent,
+			embedded:    c.Embedded,
+			auth:        c.Auth,
+		}
+	}
+	if len(f.Chain) > 0 {
+		return SetClientChain(f.Chain)
+	}
+	return nil
+}
+
+func SetClientChain(keys []string) error {
+	chain := make([]string, 0, len(keys))
+	for _, k := range keys {
+		k = strings.ToUpper(strings.TrimSpace(k))
+		if k == "" || slices.Contains(chain, k) {
+			continue
+		}
+		if _, ok := clients[k]; !ok {
+			return errors.New("unknown client: " + k)
+		}
+		chain = append(chain, k)
+	}
+	if len(chain) == 0 {
+		return errors.New("client chain is empty")
+	}
+	clientChain = chain
+	return nil
+}
@@ -0,0 +1,97 @@
+package youtube
+
+import (
+	"mpy-yt/internal/models"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const mostReplayedLabel = "Most replayed"
+
+func parseHeatmap(next any) ([]models.HeatMarker, []models.Segment) {
+	var markers []models.HeatMarker
+	var decorations []any
+	walk(next, func(key string, val any) bool {
+		if len(markers) > 0 {
+			return false
+		}
+		switch key {
+		case "heatmapRenderer":
+			for _, item := range asList(dig(val, "heatMarkers")) {
+				r := dig(item, "heatMarkerRenderer")
+				markers = append(markers, models.HeatMarker{
+					Start:     millis(r, "timeRangeStartMillis"),
+					Duration:  millis(r, "markerDurationMillis"),
+					Intensity: number(r, "heatMarkerIntensityScoreNormalized"),
+				})
+			}
+			for _, d := range asList(dig(val, "heatMarkersDecorations")) {
+				decorations = append(decorations, dig(d, "timedMarkerDecorationRenderer"))
+			}
+			return false
+		case "macroMarkersListEntity":
+			list := dig(val, "markersList")
+			if str(list, "markerType") != "MARKER_TYPE_HEATMAP" {
+				return false
+			}
+			for _, item := range asList(dig(list, "markers")) {
+				markers = append(markers, models.HeatMarker{
+					Start:     millis(item, "startMillis"),
+					Duration:  millis(item, "durationMillis"),
+					Intensity: number(item, "intensityScoreNormalized"),
+				})
+			}
+			decorations = asList(dig(list, "markersDecoration", "timedMarkerDecorations"))
+			return false
+		}
+		return true
+	})
+	if len(markers) == 0 {
+		return nil, nil
+	}
+	slices.SortFunc(markers, func(a, b models.HeatMarker) int {
+		return int(a.Start - b.Start)
+	})
+
+	var peaks []models.Segment
+	for _, d := range decorations {
+		start, end := millis(d, "visibleTimeRangeStartMillis"), millis(d, "visibleTimeRangeEndMillis")
+		if end > start {
+			peaks = append(peaks, models.Segment{Category: "heatmap", Label: mostReplayedLabel, Start: start, End: end})
+		}
+	}
+	if len(peaks) == 0 && len(markers) > 1 {
+		top := markers[1]
+		for _, m := range markers[2:] {
+			if m.Intensity > top.Intensity {
+				top = m
+			}
+		}
+		peaks = append(peaks, models.Segment{Category: "heatmap", Label: mostReplayedLabel, Start: top.Start, End: top.Start + top.Duration})
+	}
+	slices.SortFunc(peaks, func(a, b models.Segment) int {
+		return int(a.Start - b.Start)
+	})
+	return markers, peaks
+}
+
+func asList(node any) []any {
+	items, _ := node.([]any)
+	return items
+}
+
+func number(node any, key string) float64 {
+	switch v := dig(node, key).(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+func millis(node any, key string) time.Duration {
+	return time.Duration(number(node, key) * float64(time.Millisecond))
+}
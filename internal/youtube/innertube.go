@@ -0,0 +1,205 @@
+package youtube
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var apiBase = "https://www.youtube.com/youtubei/v1/"
+
+var hl, gl = "en", "US"
+
+func SetLocale(language, region string) error {
+	lang, sub, hasSub := strings.Cut(language, "-")
+	if !isLocaleTag(lang, 2, 3) || hasSub && !isLocaleTag(sub, 2, 4) {
+		return errors.New("invalid language: " + language)
+	}
+	if !isLocaleTag(region, 2, 2) {
+		return errors.New("invalid region: " + region)
+	}
+	hl, gl = language, strings.ToUpper(region)
+	return nil
+}
+
+func isLocaleTag(s string, minLen, maxLen int) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+var clientWeb = clientConfig{
+	key:     "WEB",
+	name:    "WEB",
+	version: "2.20250312.04.00",
+	id:      "1",
+	auth:    true,
+}
+
+func callApi(endpoint string, cfg clientConfig, payload map[string]any, out any) error {
+	client := map[string]any{
+		"clientName":    cfg.name,
+		"clientVersion": cfg.version,
+		"hl":            cmp.Or(cfg.hl, hl),
+		"gl":            gl,
+	}
+	if cfg.deviceModel != "" {
+		client["deviceModel"] = cfg.deviceModel
+	}
+	var tok Token
+	if endpoint == "player" {
+		videoId, _ := payload["videoId"].(string)
+		tok = currentToken(videoId)
+	}
+	visitorData := cmp.Or(tok.VisitorData, currentVisitorData())
+	if visitorData != "" {
+		client["visitorData"] = visitorData
+	}
+	if tok.PoToken != "" {
+		payload["serviceIntegrityDimensions"] = map[string]any{"poToken": tok.PoToken}
+	}
+	ctx := map[string]any{
+		"client": client,
+		"user":   map[string]any{"lockedSafetyMode": false},
+	}
+	if cfg.embedded {
+		ctx["thirdParty"] = map[string]any{"embedUrl": "https://www.youtube.com/"}
+	}
+	payload["context"] = ctx
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	base := apiBase
+	if cfg.apiBase != nil {
+		base = *cfg.apiBase
+	}
+	req, err := http.NewRequest(http.MethodPost, base+endpoint+"?prettyPrint=false", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Youtube-Client-Name", cfg.id)
+	req.Header.Set("X-Youtube-Client-Version", cfg.version)
+	if cfg.userAgent != "" {
+		req.Header.Set("User-Agent", cfg.userAgent)
+	}
+	if visitorData != "" {
+		req.Header.Set("X-Goog-Visitor-Id", visitorData)
+	}
+	if cfg.auth {
+		authorize(req)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("%w: %s request failed: %d", ErrNetwork, endpoint, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s request failed: %d", endpoint, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w: %w", ErrSchemaChanged, err)
+	}
+	return nil
+}
+
+func walk(node any, fn func(key string, val any) bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		for k, v := range n {
+			if fn(k, v) {
+				walk(v, fn)
+			}
+		}
+	case []any:
+		for _, v := range n {
+			walk(v, fn)
+		}
+	}
+}
+
+func dig(node any, path ...any) any {
+	for _, p := range path {
+		switch k := p.(type) {
+		case string:
+			m, ok := node.(map[string]any)
+			if !ok {
+				return nil
+			}
+			node = m[k]
+		case int:
+			a, ok := node.([]any)
+			if !ok || k < 0 || k >= len(a) {
+				return nil
+			}
+			node = a[k]
+		}
+	}
+	return node
+}
+
+func str(node any, path ...any) string {
+	s, _ := dig(node, path...).(string)
+	return s
+}
+
+func text(node any) string {
+	if s := str(node, "simpleText"); s != "" {
+		return s
+	}
+	if s := str(node, "content"); s != "" {
+		return s
+	}
+	runs, _ := dig(node, "runs").([]any)
+	var b strings.Builder
+	for _, r := range runs {
+		b.WriteString(str(r, "text"))
+	}
+	return b.String()
+}
+
+func continuationToken(node any) string {
+	token := ""
+	walk(node, func(key string, val any) bool {
+		if token != "" {
+			return false
+		}
+		if key == "continuationCommand" {
+			token = str(val, "token")
+			return false
+		}
+		return true
+	})
+	return token
+}
+
+func parseClock(s string) time.Duration {
+	var total int64
+	for part := range strings.SplitSeq(strings.TrimSpace(s), ":") {
+		v, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total) * time.Second
+}
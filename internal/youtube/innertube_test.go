@@ -0,0 +1,120 @@
+package youtube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type apiRequest struct {
+	Endpoint string
+	Body     map[string]any
+}
+
+func fakeApi(t *testing.T, handler func(req apiRequest) any) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := handler(apiRequest{Endpoint: strings.TrimPrefix(r.URL.Path, "/youtubei/v1/"), Body: body})
+		if code, ok := resp.(int); ok {
+			w.WriteHeader(code)
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	saved, savedMusic := apiBase, musicApiBase
+	apiBase = srv.URL + "/youtubei/v1/"
+	musicApiBase = apiBase
+	t.Cleanup(func() {
+		apiBase, musicApiBase = saved, savedMusic
+		srv.Close()
+	})
+}
+
+func fixture(t *testing.T, name string) any {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return v
+}
+
+func TestFetchPlayerDataUnknownItags(t *testing.T) {
+	player := fixture(t, "player_unknown_itags.json")
+	fakeApi(t, func(req apiRequest) any {
+		if req.Endpoint != "player" || req.Body["videoId"] != "dQw4w9WgXcQ" {
+			return http.StatusNotFound
+		}
+		return player
+	})
+
+	data, err := fetchPlayerData("dQw4w9WgXcQ", clients["ANDROID"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	qualities := map[int]string{}
+	for _, v := range data.Videos {
+		qualities[v.Itag] = v.Quality
+	}
+	want := map[int]string{
+		9999: "1080p",
+		9998: "1440p",
+		9996: "720p",
+	}
+	for itag, quality := range want {
+		if got, ok := qualities[itag]; !ok {
+			t.Errorf("itag %d missing from Videos: %v", itag, qualities)
+		} else if got != quality {
+			t.Errorf("itag %d quality = %q, want %q", itag, got, quality)
+		}
+	}
+	if _, ok := qualities[9995]; ok {
+		t.Error("itag 9995 has no label, size or known itag and should be skipped")
+	}
+	if _, ok := qualities[9994]; ok {
+		t.Error("ciphered itag 9994 should be skipped")
+	}
+	if len(data.Audios) != 1 || data.Audios[0].Itag != 140 || data.Audios[0].SampleRate != 44100 {
+		t.Errorf("unexpected audios: %+v", data.Audios)
+	}
+	if len(data.Muxed) != 1 || data.Muxed[0].Quality != "360p" {
+		t.Errorf("unexpected muxed: %+v", data.Muxed)
+	}
+	if data.Title != "Fixture video" || data.ViewCount != 1234 || len(data.Chapters) != 3 {
+		t.Errorf("unexpected metadata: %q views %d chapters %d", data.Title, data.ViewCount, len(data.Chapters))
+	}
+}
+
+func TestVideoQuality(t *testing.T) {
+	tests := []struct {
+		name   string
+		format adaptiveFormat
+		want   string
+	}{
+		{"label", adaptiveFormat{Itag: 9999, QualityLabel: "1080p60 HDR"}, "1080p"},
+		{"label beats size", adaptiveFormat{Itag: 9999, QualityLabel: "720p", Width: 1920, Height: 1080}, "720p"},
+		{"landscape size", adaptiveFormat{Itag: 9999, Width: 1280, Height: 720}, "720p"},
+		{"portrait size", adaptiveFormat{Itag: 9999, Width: 1080, Height: 1920}, "1080p"},
+		{"ultra-wide size", adaptiveFormat{Itag: 9999, Width: 2560, Height: 1080}, "1440p"},
+		{"known itag", adaptiveFormat{Itag: 137}, "1080p"},
+		{"unknown itag", adaptiveFormat{Itag: 9999}, ""},
+	}
+	for _, tt := range tests {
+		if got := videoQuality(&tt.format); got != tt.want {
+			t.Errorf("%s: videoQuality = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
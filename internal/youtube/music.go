@@ -0,0 +1,97 @@
+package youtube
+
+import (
+	"errors"
+	"mpy-yt/internal/models"
+	"strings"
+)
+
+const albumPrefix = "OLAK5uy_"
+
+var musicApiBase = "https://music.youtube.com/youtubei/v1/"
+
+var clientMusic = clientConfig{
+	key:     "WEB_REMIX",
+	name:    "WEB_REMIX",
+	version: "1.20250310.01.00",
+	id:      "67",
+	apiBase: &musicApiBase,
+}
+
+func GetMusicInfo(videoId, playlistId string) (*models.MusicInfo, error) {
+	payload := map[string]any{"videoId": videoId}
+	if playlistId != "" {
+		payload["playlistId"] = playlistId
+	}
+	var resp any
+	if err := callApi("next", clientMusic, payload, &resp); err != nil {
+		return nil, err
+	}
+	var info *models.MusicInfo
+	walk(resp, func(key string, val any) bool {
+		if info != nil {
+			return false
+		}
+		if key != "playlistPanelVideoRenderer" || str(val, "videoId") != videoId {
+			return true
+		}
+		info = musicInfo(val, strings.HasPrefix(playlistId, albumPrefix))
+		return false
+	})
+	if info == nil {
+		return nil, errors.New("no music metadata for " + videoId)
+	}
+	return info, nil
+}
+
+func musicInfo(node any, inAlbum bool) *models.MusicInfo {
+	info := &models.MusicInfo{
+		VideoType: str(node, "navigationEndpoint", "watchEndpoint", "watchEndpointMusicSupportedConfigs", "watchEndpointMusicConfig", "musicVideoType"),
+	}
+	runs, _ := dig(node, "longBylineText", "runs").([]any)
+	var artist strings.Builder
+	inArtist := true
+	for _, r := range runs {
+		t := str(r, "text")
+		if strings.TrimSpace(t) == "•" {
+			inArtist = false
+			continue
+		}
+		if inArtist {
+			artist.WriteString(t)
+		}
+		if str(r, "navigationEndpoint", "browseEndpoint", "browseEndpointContextSupportedConfigs", "browseEndpointContextMusicConfig", "pageType") == "MUSIC_PAGE_TYPE_ALBUM" {
+			info.Album = t
+		}
+	}
+	info.Artist = strings.TrimSpace(artist.String())
+	if info.Artist == "" {
+		info.Artist = text(dig(node, "shortBylineText"))
+	}
+	if index, ok := dig(node, "navigationEndpoint", "watchEndpoint", "index").(float64); ok && inAlbum {
+		info.TrackNumber = int(index) + 1
+	}
+	return info
+}
+
+func ResolveAlbum(browseId string) (Target, error) {
+	var resp any
+	if err := callApi("browse", clientMusic, map[string]any{"browseId": browseId}, &resp); err != nil {
+		return Target{}, err
+	}
+	playlistId := ""
+	walk(resp, func(key string, val any) bool {
+		if playlistId != "" {
+			return false
+		}
+		if id, ok := val.(string); ok && key == "playlistId" && strings.HasPrefix(id, albumPrefix) {
+			playlistId = id
+			return false
+		}
+		return true
+	})
+	if playlistId == "" {
+		return Target{}, errors.New("album not found: " + browseId)
+	}
+	return Target{Kind: TargetPlaylist, PlaylistId: playlistId, Music: true}, nil
+}
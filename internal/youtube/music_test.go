@@ -0,0 +1,23 @@
+package youtube
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMusicUsesApiBase(t *testing.T) {
+	var endpoints []string
+	fakeApi(t, func(req apiRequest) any {
+		endpoints = append(endpoints, req.Endpoint)
+		if got := str(req.Body, "context", "client", "clientName"); got != "WEB_REMIX" {
+			t.Errorf("client = %q, want WEB_REMIX", got)
+		}
+		return map[string]any{}
+	})
+	if _, err := GetMusicInfo("dQw4w9WgXcQ", ""); err == nil {
+		t.Error("GetMusicInfo succeeded without metadata")
+	}
+	if !slices.Equal(endpoints, []string{"next"}) {
+		t.Errorf("music requests = %v, want one next request to the test server", endpoints)
+	}
+}
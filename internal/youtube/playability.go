@@ -0,0 +1,89 @@
+package youtube
+
+import (
+	"cmp"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrLoginRequired = errors.New("login required")
+	ErrAgeRestricted = errors.New("video is age-restricted")
+	ErrMembersOnly   = errors.New("video is members-only")
+	ErrGeoBlocked    = errors.New("video is not available in this country")
+	ErrRemoved       = errors.New("video is unavailable or has been removed")
+	ErrPrivate       = errors.New("video is private")
+	ErrUpcoming      = errors.New("stream has not started yet")
+	ErrNetwork       = errors.New("network error")
+	ErrSchemaChanged = errors.New("unexpected API response")
+)
+
+type PlayabilityError struct {
+	Status        string
+	Reason        string
+	englishReason string
+}
+
+func (e *PlayabilityError) Error() string {
+	if e.Reason != "" {
+		return e.Reason
+	}
+	if e.Status != "" {
+		return e.Status
+	}
+	return "video is unplayable"
+}
+
+func (e *PlayabilityError) Is(target error) bool {
+	kind := e.kind()
+	return kind != nil && kind == target
+}
+
+func (e *PlayabilityError) kind() error {
+	reason := strings.ToLower(cmp.Or(e.englishReason, e.Reason))
+	switch {
+	case e.Status == "LIVE_STREAM_OFFLINE":
+		return ErrUpcoming
+	case strings.Contains(reason, "private"):
+		return ErrPrivate
+	case strings.Contains(reason, "member"):
+		return ErrMembersOnly
+	case strings.Contains(reason, "country"), strings.Contains(reason, "region"):
+		return ErrGeoBlocked
+	case e.Status == "AGE_CHECK_REQUIRED", e.Status == "AGE_VERIFICATION_REQUIRED",
+		strings.Contains(reason, "confirm your age"), strings.Contains(reason, "age-restricted"),
+		strings.Contains(reason, "inappropriate"):
+		return ErrAgeRestricted
+	case e.Status == "ERROR", strings.Contains(reason, "removed"), strings.Contains(reason, "terminated"),
+		strings.Contains(reason, "no longer available"):
+		return ErrRemoved
+	case e.Status == "LOGIN_REQUIRED":
+		return ErrLoginRequired
+	}
+	return nil
+}
+
+func (e *PlayabilityError) retryable() bool {
+	switch e.Status {
+	case "LOGIN_REQUIRED", "UNPLAYABLE", "AGE_CHECK_REQUIRED", "AGE_VERIFICATION_REQUIRED", "CONTENT_CHECK_REQUIRED":
+		return true
+	}
+	return false
+}
+
+func (e *PlayabilityError) needsAuth() bool {
+	if e.Status == "LOGIN_REQUIRED" {
+		return true
+	}
+	kind := e.kind()
+	return kind == ErrAgeRestricted || kind == ErrMembersOnly
+}
+
+func englishReason(videoId string, cfg clientConfig) string {
+	cfg.hl = "en"
+	var resp playerApiResponse
+	if err := callApi("player", cfg, playerPayload(videoId), &resp); err != nil {
+		return ""
+	}
+	return resp.PlayabilityStatus.Reason
+}
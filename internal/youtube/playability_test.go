@@ -0,0 +1,86 @@
+package youtube
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestPlayabilityKind(t *testing.T) {
+	tests := []struct {
+		status, reason string
+		want           error
+	}{
+		{"LIVE_STREAM_OFFLINE", "", ErrUpcoming},
+		{"LOGIN_REQUIRED", "This video is private", ErrPrivate},
+		{"UNPLAYABLE", "Join this channel to get access to members-only content like this video", ErrMembersOnly},
+		{"UNPLAYABLE", "The uploader has not made this video available in your country", ErrGeoBlocked},
+		{"LOGIN_REQUIRED", "Sign in to confirm your age", ErrAgeRestricted},
+		{"AGE_CHECK_REQUIRED", "", ErrAgeRestricted},
+		{"ERROR", "Video unavailable", ErrRemoved},
+		{"UNPLAYABLE", "This video has been removed by the uploader", ErrRemoved},
+		{"LOGIN_REQUIRED", "Sign in to confirm you're not a bot", ErrLoginRequired},
+		{"UNPLAYABLE", "Something else", nil},
+	}
+	for _, tt := range tests {
+		err := &PlayabilityError{Status: tt.status, Reason: tt.reason}
+		if got := err.kind(); got != tt.want {
+			t.Errorf("kind(%s, %q) = %v, want %v", tt.status, tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestPlayabilityLocalizedReason(t *testing.T) {
+	savedHl, savedGl := hl, gl
+	t.Cleanup(func() { hl, gl = savedHl, savedGl })
+	if err := SetLocale("de", "DE"); err != nil {
+		t.Fatal(err)
+	}
+
+	var languages []string
+	fakeApi(t, func(req apiRequest) any {
+		lang := str(req.Body, "context", "client", "hl")
+		languages = append(languages, lang)
+		reason := "Dieses Video ist privat."
+		if lang == "en" {
+			reason = "This video is private."
+		}
+		return map[string]any{"playabilityStatus": map[string]any{"status": "LOGIN_REQUIRED", "reason": reason}}
+	})
+
+	_, err := fetchPlayerData("dQw4w9WgXcQ", clients["ANDROID"])
+	if !errors.Is(err, ErrPrivate) {
+		t.Errorf("error %v is not classified as private", err)
+	}
+	if err == nil || err.Error() != "Dieses Video ist privat." {
+		t.Errorf("error message = %v, want the localized reason", err)
+	}
+	if len(languages) != 2 || languages[0] != "de" || languages[1] != "en" {
+		t.Errorf("request languages = %v, want [de en]", languages)
+	}
+}
+
+func TestCallApiStatusErrors(t *testing.T) {
+	tests := []struct {
+		code    int
+		network bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		fakeApi(t, func(req apiRequest) any { return tt.code })
+		var out any
+		err := callApi("browse", clientWeb, map[string]any{}, &out)
+		if err == nil {
+			t.Errorf("status %d: no error", tt.code)
+			continue
+		}
+		if errors.Is(err, ErrNetwork) != tt.network {
+			t.Errorf("status %d: errors.Is(ErrNetwork) = %v, want %v", tt.code, !tt.network, tt.network)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package youtube
+
+import (
+	"errors"
+	"mpy-yt/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxPlaylistPages = 100
+
+var playlistPrefixes = [...]string{"PL", "UU", "LL", "FL", "RD", albumPrefix, "UL", "EL", "PU", "WL"}
+
+func ExtractPlaylistId(input string) string {
+	t, err := Parse(input)
+	if err != nil {
+		return ""
+	}
+	return t.PlaylistId
+}
+
+func isBarePlaylistId(s string) bool {
+	if len(s) < 12 || !isValidPlaylistId(s) {
+		return false
+	}
+	for _, p := range playlistPrefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidPlaylistId(s string) bool {
+	if len(s) > 64 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		valid := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_'
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+func GetPlaylist(playlistId, videoId string) (*models.Playlist, error) {
+	if strings.HasPrefix(playlistId, "RD") {
+		return fetchMix(playlistId, videoId)
+	}
+
+	var resp any
+	if err := callApi("browse", clientWeb, map[string]any{"browseId": "VL" + playlistId}, &resp); err != nil {
+		return nil, err
+	}
+	if msg := alertMessage(resp); msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	pl := &models.Playlist{
+		Id:    playlistId,
+		Title: str(resp, "metadata", "playlistMetadataRenderer", "title"),
+	}
+	token := appendPlaylistEntries(pl, resp)
+	for page := 1; token != "" && page < maxPlaylistPages; page++ {
+		var next any
+		if err := callApi("browse", clientWeb, map[string]any{"continuation": token}, &next); err != nil {
+			return nil, err
+		}
+		token = appendPlaylistEntries(pl, dig(next, "onResponseReceivedActions"))
+	}
+
+	if len(pl.Entries) == 0 {
+		return nil, errors.New("playlist is empty or unavailable")
+	}
+	return pl, nil
+}
+
+func appendPlaylistEntries(pl *models.Playlist, node any) string {
+	token := ""
+	walk(node, func(key string, val any) bool {
+		switch key {
+		case "playlistVideoRenderer":
+			id := str(val, "videoId")
+			if id == "" {
+				return false
+			}
+			if playable, ok := dig(val, "isPlayable").(bool); ok && !playable {
+				return false
+			}
+			secs, _ := strconv.ParseInt(str(val, "lengthSeconds"), 10, 64)
+			pl.Entries = append(pl.Entries, models.PlaylistEntry{
+				VideoId:  id,
+				Title:    text(dig(val, "title")),
+				Channel:  text(dig(val, "shortBylineText")),
+				Duration: time.Duration(secs) * time.Second,
+			})
+			return false
+		case "continuationItemRenderer":
+			token = continuationToken(val)
+			return false
+		}
+		return true
+	})
+	return token
+}
+
+func fetchMix(playlistId, videoId string) (*models.Playlist, error) {
+	payload := map[string]any{"playlistId": playlistId}
+	if videoId != "" {
+		payload["videoId"] = videoId
+	}
+	var resp any
+	if err := callApi("next", clientWeb, payload, &resp); err != nil {
+		return nil, err
+	}
+
+	panel := dig(resp, "contents", "twoColumnWatchNextResults", "playlist", "playlist")
+	pl := &models.Playlist{
+		Id:    playlistId,
+		Title: text(dig(panel, "title")),
+	}
+	walk(dig(panel, "contents"), func(key string, val any) bool {
+		if key != "playlistPanelVideoRenderer" {
+			return true
+		}
+		if id := str(val, "videoId"); id != "" {
+			pl.Entries = append(pl.Entries, models.PlaylistEntry{
+				VideoId:  id,
+				Title:    text(dig(val, "title")),
+				Channel:  text(dig(val, "shortBylineText")),
+				Duration: parseClock(text(dig(val, "lengthText"))),
+			})
+		}
+		return false
+	})
+
+	if len(pl.Entries) == 0 {
+		return nil, errors.New("mix is empty or unavailable")
+	}
+	return pl, nil
+}
+
+func alertMessage(resp any) string {
+	msg := ""
+	walk(dig(resp, "alerts"), func(key string, val any) bool {
+		if key == "alertRenderer" && str(val, "type") == "ERROR" && msg == "" {
+			msg = text(dig(val, "text"))
+		}
+		return msg == ""
+	})
+	return msg
+}
@@ -0,0 +1,106 @@
+package youtube
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func playlistVideo(id, title string, playable bool) any {
+	return map[string]any{"playlistVideoRenderer": map[string]any{
+		"videoId":         id,
+		"title":           map[string]any{"runs": []any{map[string]any{"text": title}}},
+		"shortBylineText": map[string]any{"runs": []any{map[string]any{"text": "Fixture channel"}}},
+		"lengthSeconds":   "61",
+		"isPlayable":      playable,
+	}}
+}
+
+func continuationItem(token string) any {
+	return map[string]any{"continuationItemRenderer": map[string]any{
+		"continuationEndpoint": map[string]any{"continuationCommand": map[string]any{"token": token}},
+	}}
+}
+
+func TestGetPlaylistPaging(t *testing.T) {
+	const list = "PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf"
+	var requests []string
+	fakeApi(t, func(req apiRequest) any {
+		if req.Endpoint != "browse" {
+			return http.StatusNotFound
+		}
+		switch {
+		case req.Body["browseId"] == "VL"+list:
+			requests = append(requests, "first")
+			return map[string]any{
+				"metadata": map[string]any{"playlistMetadataRenderer": map[string]any{"title": "Fixture playlist"}},
+				"contents": map[string]any{"playlistVideoListRenderer": map[string]any{"contents": []any{
+					playlistVideo("dQw4w9WgXcQ", "One", true),
+					playlistVideo("jNQXAC9IVRw", "Two", true),
+					continuationItem("page-2"),
+				}}},
+			}
+		case req.Body["continuation"] == "page-2":
+			requests = append(requests, "page-2")
+			return map[string]any{"onResponseReceivedActions": []any{map[string]any{
+				"appendContinuationItemsAction": map[string]any{"continuationItems": []any{
+					playlistVideo("xxxxxxxxxxx", "Deleted", false),
+					playlistVideo("9bZkp7q19f0", "Three", true),
+					continuationItem("page-3"),
+				}},
+			}}}
+		case req.Body["continuation"] == "page-3":
+			requests = append(requests, "page-3")
+			return map[string]any{"onResponseReceivedActions": []any{map[string]any{
+				"appendContinuationItemsAction": map[string]any{"continuationItems": []any{
+					playlistVideo("kJQP7kiw5Fk", "Four", true),
+				}},
+			}}}
+		}
+		return http.StatusBadRequest
+	})
+
+	pl, err := GetPlaylist(list, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl.Title != "Fixture playlist" {
+		t.Errorf("title = %q", pl.Title)
+	}
+	want := []string{"dQw4w9WgXcQ", "jNQXAC9IVRw", "9bZkp7q19f0", "kJQP7kiw5Fk"}
+	if len(pl.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(pl.Entries), len(want), pl.Entries)
+	}
+	for i, id := range want {
+		if pl.Entries[i].VideoId != id {
+			t.Errorf("entry %d = %s, want %s", i, pl.Entries[i].VideoId, id)
+		}
+	}
+	if e := pl.Entries[0]; e.Title != "One" || e.Channel != "Fixture channel" || e.Duration != 61*time.Second {
+		t.Errorf("unexpected first entry: %+v", e)
+	}
+	if len(requests) != 3 {
+		t.Errorf("requests = %v, want three pages", requests)
+	}
+}
+
+func TestGetPlaylistAlert(t *testing.T) {
+	fakeApi(t, func(req apiRequest) any {
+		return map[string]any{"alerts": []any{map[string]any{"alertRenderer": map[string]any{
+			"type": "ERROR",
+			"text": map[string]any{"runs": []any{map[string]any{"text": "The playlist does not exist."}}},
+		}}}}
+	})
+	if _, err := GetPlaylist("PLdoesnotexist000", ""); err == nil || err.Error() != "The playlist does not exist." {
+		t.Errorf("GetPlaylist error = %v", err)
+	}
+}
+
+func TestGetPlaylistHttpError(t *testing.T) {
+	fakeApi(t, func(req apiRequest) any {
+		return http.StatusInternalServerError
+	})
+	if _, err := GetPlaylist("WL", ""); err == nil {
+		t.Error("GetPlaylist succeeded on a server error")
+	}
+}
@@ -0,0 +1,205 @@
+package youtube
+
+import (
+	"encoding/base64"
+	"errors"
+	"mpy-yt/internal/models"
+	"net/url"
+)
+
+type SearchFilter struct {
+	Duration string
+	Date     string
+	Type     string
+}
+
+var (
+	searchDates     = map[string]byte{"hour": 1, "today": 2, "week": 3, "month": 4, "year": 5}
+	searchTypes     = map[string]byte{"video": 1, "channel": 2, "playlist": 3, "movie": 4}
+	searchDurations = map[string]byte{"short": 1, "long": 2, "medium": 3}
+)
+
+type Page struct {
+	Title        string
+	Items        []models.ListItem
+	endpoint     string
+	continuation string
+}
+
+func (p *Page) HasMore() bool {
+	return p.continuation != ""
+}
+
+func (p *Page) Next() (*Page, error) {
+	if p.continuation == "" {
+		return nil, errors.New("no more results")
+	}
+	var resp any
+	if err := callApi(p.endpoint, clientWeb, map[string]any{"continuation": p.continuation}, &resp); err != nil {
+		return nil, err
+	}
+	next := parsePage(p.endpoint, resp)
+	next.Title = p.Title
+	return next, nil
+}
+
+func Search(query string, filter SearchFilter) (*Page, error) {
+	payload := map[string]any{"query": query}
+	params, err := searchParams(filter)
+	if err != nil {
+		return nil, err
+	}
+	if params != "" {
+		payload["params"] = params
+	}
+	var resp any
+	if err := callApi("search", clientWeb, payload, &resp); err != nil {
+		return nil, err
+	}
+	return parsePage("search", resp), nil
+}
+
+func searchParams(f SearchFilter) (string, error) {
+	var inner []byte
+	for _, opt := range [...]struct {
+		name   string
+		value  string
+		tag    byte
+		values map[string]byte
+	}{
+		{"date", f.Date, 0x08, searchDates},
+		{"type", f.Type, 0x10, searchTypes},
+		{"duration", f.Duration, 0x18, searchDurations},
+	} {
+		if opt.value == "" {
+			continue
+		}
+		v, ok := opt.values[opt.value]
+		if !ok {
+			return "", errors.New("invalid " + opt.name + " filter: " + opt.value)
+		}
+		inner = append(inner, opt.tag, v)
+	}
+	if len(inner) == 0 {
+		return "", nil
+	}
+	msg := append([]byte{0x12, byte(len(inner))}, inner...)
+	return url.QueryEscape(base64.StdEncoding.EncodeToString(msg)), nil
+}
+
+func parsePage(endpoint string, resp any) *Page {
+	page := &Page{endpoint: endpoint}
+	walk(resp, func(key string, val any) bool {
+		switch key {
+		case "shelfRenderer", "reelShelfRenderer", "horizontalCardListRenderer", "secondarySearchContainerRenderer":
+			return false
+		case "continuationItemRenderer":
+			page.continuation = continuationToken(val)
+			return false
+		}
+		if item, ok := parseItem(key, val); ok {
+			page.Items = append(page.Items, item)
+			return false
+		}
+		return true
+	})
+	return page
+}
+
+func parseItem(key string, val any) (models.ListItem, bool) {
+	switch key {
+	case "videoRenderer":
+		id := str(val, "videoId")
+		if id == "" {
+			return models.ListItem{}, false
+		}
+		channel := text(dig(val, "ownerText"))
+		if channel == "" {
+			channel = text(dig(val, "longBylineText"))
+		}
+		views := text(dig(val, "shortViewCountText"))
+		if views == "" {
+			views = text(dig(val, "viewCountText"))
+		}
+		return models.ListItem{
+			Kind:      models.ItemVideo,
+			Id:        id,
+			Title:     text(dig(val, "title")),
+			Channel:   channel,
+			Duration:  text(dig(val, "lengthText")),
+			Views:     views,
+			Published: text(dig(val, "publishedTimeText")),
+		}, true
+	case "playlistRenderer":
+		id := str(val, "playlistId")
+		if id == "" {
+			return models.ListItem{}, false
+		}
+		channel := text(dig(val, "shortBylineText"))
+		if channel == "" {
+			channel = text(dig(val, "longBylineText"))
+		}
+		return models.ListItem{
+			Kind:       models.ItemPlaylist,
+			Id:         id,
+			Title:      text(dig(val, "title")),
+			Channel:    channel,
+			VideoCount: str(val, "videoCount"),
+		}, true
+	case "reelItemRenderer":
+		id := str(val, "videoId")
+		if id == "" {
+			return models.ListItem{}, false
+		}
+		return models.ListItem{
+			Kind:  models.ItemVideo,
+			Id:    id,
+			Title: text(dig(val, "headline")),
+			Views: text(dig(val, "viewCountText")),
+		}, true
+	case "shortsLockupViewModel":
+		id := str(val, "onTap", "innertubeCommand", "reelWatchEndpoint", "videoId")
+		if id == "" {
+			return models.ListItem{}, false
+		}
+		return models.ListItem{
+			Kind:  models.ItemVideo,
+			Id:    id,
+			Title: text(dig(val, "overlayMetadata", "primaryText")),
+			Views: text(dig(val, "overlayMetadata", "secondaryText")),
+		}, true
+	case "gridPlaylistRenderer":
+		id := str(val, "playlistId")
+		if id == "" {
+			return models.ListItem{}, false
+		}
+		return models.ListItem{
+			Kind:       models.ItemPlaylist,
+			Id:         id,
+			Title:      text(dig(val, "title")),
+			VideoCount: text(dig(val, "videoCountShortText")),
+		}, true
+	case "channelRenderer":
+		id := str(val, "channelId")
+		if id == "" {
+			return models.ListItem{}, false
+		}
+		return models.ListItem{
+			Kind:  models.ItemChannel,
+			Id:    id,
+			Title: text(dig(val, "title")),
+			Views: text(dig(val, "videoCountText")),
+		}, true
+	case "lockupViewModel":
+		id := str(val, "contentId")
+		if id == "" || str(val, "contentType") != "LOCKUP_CONTENT_TYPE_PLAYLIST" {
+			return models.ListItem{}, false
+		}
+		return models.ListItem{
+			Kind:  models.ItemPlaylist,
+			Id:    id,
+			Title: text(dig(val, "metadata", "lockupMetadataViewModel", "title")),
+		}, true
+	}
+	return models.ListItem{}, false
+}
@@ -0,0 +1,108 @@
+package youtube
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSearchParams(t *testing.T) {
+	tests := []struct {
+		filter SearchFilter
+		want   string
+	}{
+		{SearchFilter{}, ""},
+		{SearchFilter{Type: "video"}, "EgIQAQ%3D%3D"},
+		{SearchFilter{Type: "playlist"}, "EgIQAw%3D%3D"},
+		{SearchFilter{Date: "today"}, "EgIIAg%3D%3D"},
+		{SearchFilter{Duration: "long"}, "EgIYAg%3D%3D"},
+		{SearchFilter{Date: "week", Type: "video", Duration: "short"}, "EgYIAxABGAE%3D"},
+	}
+	for _, tt := range tests {
+		got, err := searchParams(tt.filter)
+		if err != nil || got != tt.want {
+			t.Errorf("searchParams(%+v) = %q, %v; want %q", tt.filter, got, err, tt.want)
+		}
+	}
+	for _, bad := range []SearchFilter{{Type: "podcast"}, {Date: "decade"}, {Duration: "forever"}} {
+		if _, err := searchParams(bad); err == nil {
+			t.Errorf("searchParams(%+v) accepted an invalid filter", bad)
+		}
+	}
+}
+
+func searchResult(id string) map[string]any {
+	return map[string]any{"videoRenderer": map[string]any{
+		"videoId": id,
+		"title":   map[string]any{"runs": []any{map[string]any{"text": "Video " + id}}},
+	}}
+}
+
+func TestSearchPaging(t *testing.T) {
+	var requests []apiRequest
+	fakeApi(t, func(req apiRequest) any {
+		requests = append(requests, req)
+		switch req.Body["continuation"] {
+		case nil:
+			return map[string]any{"contents": map[string]any{"sectionListRenderer": map[string]any{"contents": []any{
+				map[string]any{"itemSectionRenderer": map[string]any{"contents": []any{
+					searchResult("aaaaaaaaaaa"),
+					map[string]any{"shelfRenderer": map[string]any{"content": []any{searchResult("zzzzzzzzzzz")}}},
+					searchResult("bbbbbbbbbbb"),
+				}}},
+				continuationItem("page2"),
+			}}}}
+		case "page2":
+			return map[string]any{"onResponseReceivedCommands": []any{map[string]any{
+				"appendContinuationItemsAction": map[string]any{"continuationItems": []any{
+					searchResult("ccccccccccc"),
+				}},
+			}}}
+		}
+		return 404
+	})
+
+	page, err := Search("never gonna", SearchFilter{Type: "video"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(p *Page) []string {
+		var ids []string
+		for _, item := range p.Items {
+			ids = append(ids, item.Id)
+		}
+		return ids
+	}
+	if got := ids(page); !slices.Equal(got, []string{"aaaaaaaaaaa", "bbbbbbbbbbb"}) {
+		t.Errorf("first page = %v", got)
+	}
+	if page.Items[0].Title != "Video aaaaaaaaaaa" {
+		t.Errorf("title = %q", page.Items[0].Title)
+	}
+	if !page.HasMore() {
+		t.Fatal("first page has no continuation")
+	}
+	next, err := page.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(next); !slices.Equal(got, []string{"ccccccccccc"}) {
+		t.Errorf("second page = %v", got)
+	}
+	if next.HasMore() {
+		t.Error("last page reports more results")
+	}
+	if _, err := next.Next(); err == nil {
+		t.Error("Next past the last page succeeded")
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("made %d requests, want 2", len(requests))
+	}
+	first := requests[0]
+	if first.Endpoint != "search" || first.Body["query"] != "never gonna" || first.Body["params"] != "EgIQAQ%3D%3D" {
+		t.Errorf("first request = %s %v", first.Endpoint, first.Body)
+	}
+	if requests[1].Endpoint != "search" {
+		t.Errorf("continuation went to %s", requests[1].Endpoint)
+	}
+}
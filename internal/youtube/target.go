@@ -0,0 +1,336 @@
+package youtube
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxRedirectDepth = 4
+
+type TargetKind int
+
+const (
+	TargetVideo TargetKind = iota
+	TargetPlaylist
+	TargetPlaylistVideo
+	TargetChannel
+	TargetClip
+	TargetAlbum
+)
+
+type Target struct {
+	Kind       TargetKind
+	VideoId    string
+	PlaylistId string
+	Index      int
+	Channel    string
+	ClipId     string
+	AlbumId    string
+	Music      bool
+	Start      time.Duration
+	End        time.Duration
+}
+
+var errNotYoutube = errors.New("not a YouTube URL or ID")
+
+var linkPattern = regexp.MustCompile(`(?i)(?:https?://|\b(?:www\.|m\.|music\.)?(?:youtube\.com|youtu\.be|youtube-nocookie\.com)/)[^\s<>"'\x60]+`)
+
+func FindTargets(text string) []Target {
+	var targets []Target
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if t, err := Parse(line); err == nil {
+			targets = append(targets, t)
+			continue
+		}
+		for _, link := range linkPattern.FindAllString(line, -1) {
+			link = strings.TrimRight(link, ".,;:!?)]}*_")
+			if t, err := Parse(link); err == nil {
+				targets = append(targets, t)
+			}
+		}
+	}
+	return targets
+}
+
+func Parse(input string) (Target, error) {
+	return parse(input, 0)
+}
+
+func parse(input string, depth int) (Target, error) {
+	if depth > maxRedirectDepth {
+		return Target{}, errors.New("too many nested redirects")
+	}
+	s := strings.TrimSpace(input)
+	for i := 0; i < 3 && !strings.Contains(s, "://") && (strings.Contains(strings.ToLower(s), "%3a%2f%2f") || strings.Contains(strings.ToLower(s), "%253a%252f%252f")); i++ {
+		unescaped, err := url.QueryUnescape(s)
+		if err != nil {
+			break
+		}
+		s = unescaped
+	}
+	if s == "" {
+		return Target{}, errNotYoutube
+	}
+
+	switch {
+	case isValidId(s):
+		return Target{Kind: TargetVideo, VideoId: s}, nil
+	case isChannelId(s):
+		return Target{Kind: TargetChannel, Channel: s}, nil
+	case strings.HasPrefix(s, "@") && isHandle(s[1:]):
+		return Target{Kind: TargetChannel, Channel: s}, nil
+	case isBarePlaylistId(s):
+		return Target{Kind: TargetPlaylist, PlaylistId: s, Music: strings.HasPrefix(s, albumPrefix)}, nil
+	case isAlbumId(s):
+		return Target{Kind: TargetAlbum, AlbumId: s, Music: true}, nil
+	}
+
+	if len(s) > 11 && isValidId(s[:11]) && s[11] <= ' ' {
+		return Target{Kind: TargetVideo, VideoId: s[:11]}, nil
+	}
+	if len(s) > 11 && isValidId(s[:11]) && strings.ContainsRune("&?/", rune(s[11])) {
+		s = "https://www.youtube.com/watch?v=" + s[:11] + "&" + strings.TrimLeft(s[12:], "&?/")
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return Target{}, errNotYoutube
+	}
+	host := strings.ToLower(u.Hostname())
+	music := strings.HasPrefix(host, "music.")
+	for _, prefix := range [...]string{"www.", "m.", "music.", "gaming."} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	q := u.Query()
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	first, second := "", ""
+	if len(segments) > 0 {
+		first = segments[0]
+	}
+	if len(segments) > 1 {
+		second = segments[1]
+	}
+
+	var t Target
+	switch {
+	case host == "youtu.be":
+		t.VideoId = first
+	case strings.HasPrefix(host, "google.") && u.Path == "/url":
+		next := q.Get("q")
+		if next == "" {
+			next = q.Get("url")
+		}
+		return parse(next, depth+1)
+	case host == "youtube.com" || host == "youtube-nocookie.com":
+		switch first {
+		case "attribution_link":
+			return parse("https://www.youtube.com"+q.Get("u"), depth+1)
+		case "redirect":
+			return parse(q.Get("q"), depth+1)
+		case "watch":
+			t.VideoId = q.Get("v")
+			if t.VideoId == "" {
+				t.VideoId = second
+			}
+		case "playlist":
+		case "browse":
+			if isAlbumId(second) {
+				return Target{Kind: TargetAlbum, AlbumId: second, Music: true}, nil
+			}
+			list, ok := strings.CutPrefix(second, "VL")
+			if !ok || !isBarePlaylistId(list) {
+				return Target{}, errNotYoutube
+			}
+			q.Set("list", list)
+		case "shorts", "live", "v", "e":
+			t.VideoId = second
+		case "embed":
+			if second != "videoseries" {
+				t.VideoId = second
+			}
+		case "clip":
+			if second == "" {
+				return Target{}, errNotYoutube
+			}
+			return Target{Kind: TargetClip, ClipId: second}, nil
+		case "channel":
+			if !isChannelId(second) {
+				return Target{}, errNotYoutube
+			}
+			return Target{Kind: TargetChannel, Channel: second}, nil
+		case "c", "user":
+			if second == "" {
+				return Target{}, errNotYoutube
+			}
+			return Target{Kind: TargetChannel, Channel: first + "/" + second}, nil
+		default:
+			if handle, ok := strings.CutPrefix(first, "@"); ok && isHandle(handle) {
+				return Target{Kind: TargetChannel, Channel: first}, nil
+			}
+			return Target{}, errNotYoutube
+		}
+	default:
+		return Target{}, errNotYoutube
+	}
+
+	if t.VideoId != "" && !isValidId(t.VideoId) {
+		return Target{}, errNotYoutube
+	}
+	if list := q.Get("list"); isValidPlaylistId(list) && len(list) >= 2 {
+		t.PlaylistId = list
+		t.Index, _ = strconv.Atoi(q.Get("index"))
+	}
+	t.Music = music || strings.HasPrefix(t.PlaylistId, albumPrefix)
+	switch {
+	case t.VideoId != "" && t.PlaylistId != "":
+		t.Kind = TargetPlaylistVideo
+	case t.VideoId != "":
+		t.Kind = TargetVideo
+	case t.PlaylistId != "":
+		t.Kind = TargetPlaylist
+	default:
+		return Target{}, errNotYoutube
+	}
+
+	for _, key := range [...]string{"t", "start", "time_continue"} {
+		if d, err := ParseTime(q.Get(key)); err == nil {
+			t.Start = d
+			break
+		}
+	}
+	if d, err := ParseTime(q.Get("end")); err == nil {
+		t.End = d
+	}
+	if frag, err := url.ParseQuery(u.Fragment); err == nil {
+		if d, err := ParseTime(frag.Get("t")); err == nil {
+			t.Start = d
+		}
+	}
+	return t, nil
+}
+
+func isAlbumId(s string) bool {
+	return strings.HasPrefix(s, "MPREb_") && len(s) > 6 && isValidPlaylistId(s)
+}
+
+func isHandle(s string) bool {
+	if s == "" || len(s) > 100 {
+		return false
+	}
+	for _, c := range s {
+		if c == '/' || c == '?' || c == '#' || c == '&' || c <= ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+func ParseTime(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, errors.New("empty time")
+	}
+	if strings.Contains(s, ":") {
+		var total float64
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, errors.New("invalid time: " + s)
+		}
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil || v < 0 || (i < len(parts)-1 && strings.Contains(part, ".")) {
+				return 0, errors.New("invalid time: " + s)
+			}
+			total = total*60 + v
+		}
+		return time.Duration(total * float64(time.Second)), nil
+	}
+
+	var total float64
+	units := map[byte]float64{'h': 3600, 'm': 60, 's': 1}
+	for s != "" {
+		end := 0
+		for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+			end++
+		}
+		v, err := strconv.ParseFloat(s[:end], 64)
+		if err != nil {
+			return 0, errors.New("invalid time: " + s)
+		}
+		s = s[end:]
+		if s == "" {
+			total += v
+			break
+		}
+		mult, ok := units[s[0]]
+		if !ok {
+			return 0, errors.New("invalid time unit: " + s)
+		}
+		total += v * mult
+		s = s[1:]
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
+func ResolveClip(clipId string) (Target, error) {
+	var resp any
+	payload := map[string]any{"url": "https://www.youtube.com/clip/" + clipId}
+	if err := callApi("navigation/resolve_url", clientWeb, payload, &resp); err != nil {
+		return Target{}, err
+	}
+	endpoint := dig(resp, "endpoint", "watchEndpoint")
+	t := Target{Kind: TargetVideo, VideoId: str(endpoint, "videoId"), ClipId: clipId}
+	if !isValidId(t.VideoId) {
+		return Target{}, errors.New("clip not found: " + clipId)
+	}
+
+	var next any
+	payload = map[string]any{"videoId": t.VideoId}
+	if params := str(endpoint, "params"); params != "" {
+		payload["params"] = params
+	}
+	if err := callApi("next", clientWeb, payload, &next); err != nil {
+		return Target{}, err
+	}
+	for _, node := range [...]any{endpoint, next} {
+		if start, end, ok := clipRange(node); ok {
+			t.Start, t.End = start, end
+			return t, nil
+		}
+	}
+	if secs, err := strconv.Atoi(str(endpoint, "startTimeSeconds")); err == nil {
+		t.Start = time.Duration(secs) * time.Second
+	}
+	return t, nil
+}
+
+func clipRange(node any) (time.Duration, time.Duration, bool) {
+	var start, end int64
+	found := false
+	walk(node, func(key string, val any) bool {
+		if found {
+			return false
+		}
+		if key != "clipConfig" && key != "loopCommand" {
+			return true
+		}
+		s, err1 := strconv.ParseInt(str(val, "startTimeMs"), 10, 64)
+		e, err2 := strconv.ParseInt(str(val, "endTimeMs"), 10, 64)
+		if err1 == nil && err2 == nil && e > s {
+			start, end, found = s, e, true
+			return false
+		}
+		return true
+	})
+	return time.Duration(start) * time.Millisecond, time.Duration(end) * time.Millisecond, found
+}
@@ -0,0 +1,137 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	const id = "dQw4w9WgXcQ"
+	const list = "PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf"
+	video := Target{Kind: TargetVideo, VideoId: id}
+	at := func(t Target, start time.Duration) Target {
+		t.Start = start
+		return t
+	}
+	music := video
+	music.Music = true
+
+	tests := []struct {
+		name  string
+		input string
+		want  Target
+		err   bool
+	}{
+		{"bare id", id, video, false},
+		{"bare id with spaces", "  " + id + "\n", video, false},
+		{"bare id with ampersand", id + "&t=10", at(video, 10*time.Second), false},
+		{"bare id with query", id + "?t=1m5s", at(video, 65*time.Second), false},
+		{"bare id with slash", id + "/", video, false},
+		{"bare id with list", id + "&list=" + list, Target{Kind: TargetPlaylistVideo, VideoId: id, PlaylistId: list}, false},
+		{"bare id with text", id + " great song", video, false},
+		{"bare id with tab", id + "\t3:32", video, false},
+		{"watch", "https://www.youtube.com/watch?v=" + id, video, false},
+		{"watch without scheme", "youtube.com/watch?v=" + id, video, false},
+		{"watch with other params first", "https://www.youtube.com/watch?feature=share&v=" + id, video, false},
+		{"mobile", "https://m.youtube.com/watch?v=" + id, video, false},
+		{"music", "https://music.youtube.com/watch?v=" + id, music, false},
+		{"nocookie embed", "https://www.youtube-nocookie.com/embed/" + id, video, false},
+		{"embed", "https://www.youtube.com/embed/" + id + "?start=30", at(video, 30*time.Second), false},
+		{"embed videoseries", "https://www.youtube.com/embed/videoseries?list=" + list, Target{Kind: TargetPlaylist, PlaylistId: list}, false},
+		{"short link", "https://youtu.be/" + id, video, false},
+		{"short link with t", "https://youtu.be/" + id + "?t=42", at(video, 42*time.Second), false},
+		{"shorts", "https://www.youtube.com/shorts/" + id, video, false},
+		{"live", "https://www.youtube.com/live/" + id + "?feature=share", video, false},
+		{"legacy v", "https://www.youtube.com/v/" + id, video, false},
+		{"fragment t", "https://www.youtube.com/watch?v=" + id + "#t=1:02:03", at(video, time.Hour+2*time.Minute+3*time.Second), false},
+		{"time_continue", "https://www.youtube.com/watch?v=" + id + "&time_continue=15", at(video, 15*time.Second), false},
+		{"end", "https://www.youtube.com/watch?v=" + id + "&end=20", Target{Kind: TargetVideo, VideoId: id, End: 20 * time.Second}, false},
+		{"attribution link", "https://www.youtube.com/attribution_link?a=x&u=%2Fwatch%3Fv%3D" + id + "%26feature%3Dshare", video, false},
+		{"redirect", "https://www.youtube.com/redirect?q=https%3A%2F%2Fyoutu.be%2F" + id, video, false},
+		{"google url", "https://www.google.com/url?sa=t&url=https%3A%2F%2Fwww.youtube.com%2Fwatch%3Fv%3D" + id, video, false},
+		{"google url q", "https://www.google.co.uk/url?q=https://youtu.be/" + id, video, false},
+		{"percent-encoded", "https%3A%2F%2Fwww.youtube.com%2Fwatch%3Fv%3D" + id, video, false},
+		{"double percent-encoded", "https%253A%252F%252Fyoutu.be%252F" + id, video, false},
+		{"encoded text in a plain url", "https://www.youtube.com/watch?v=" + id + "&list=" + list + "&from=a%253A%252F%252Fb%2526index%253D7", Target{Kind: TargetPlaylistVideo, VideoId: id, PlaylistId: list}, false},
+		{"playlist", "https://www.youtube.com/playlist?list=" + list, Target{Kind: TargetPlaylist, PlaylistId: list}, false},
+		{"bare playlist", list, Target{Kind: TargetPlaylist, PlaylistId: list}, false},
+		{"playlist video with index", "https://www.youtube.com/watch?v=" + id + "&list=" + list + "&index=7", Target{Kind: TargetPlaylistVideo, VideoId: id, PlaylistId: list, Index: 7}, false},
+		{"album", "https://music.youtube.com/playlist?list=OLAK5uy_kqX2AoWmaR2hT1ccvWPA5cF6pXBKF8C0g", Target{Kind: TargetPlaylist, PlaylistId: "OLAK5uy_kqX2AoWmaR2hT1ccvWPA5cF6pXBKF8C0g", Music: true}, false},
+		{"album browse", "https://music.youtube.com/browse/MPREb_4pL8gzRtw1p", Target{Kind: TargetAlbum, AlbumId: "MPREb_4pL8gzRtw1p", Music: true}, false},
+		{"clip", "https://www.youtube.com/clip/UgkxU2HSeGL_NvmDJ-nQJrlLwllwMDBdGZFs", Target{Kind: TargetClip, ClipId: "UgkxU2HSeGL_NvmDJ-nQJrlLwllwMDBdGZFs"}, false},
+		{"channel id", "https://www.youtube.com/channel/UCuAXFkgsw1L7xaCfnd5JJOw", Target{Kind: TargetChannel, Channel: "UCuAXFkgsw1L7xaCfnd5JJOw"}, false},
+		{"bare channel id", "UCuAXFkgsw1L7xaCfnd5JJOw", Target{Kind: TargetChannel, Channel: "UCuAXFkgsw1L7xaCfnd5JJOw"}, false},
+		{"handle", "https://www.youtube.com/@RickAstleyYT/videos", Target{Kind: TargetChannel, Channel: "@RickAstleyYT"}, false},
+		{"bare handle", "@RickAstleyYT", Target{Kind: TargetChannel, Channel: "@RickAstleyYT"}, false},
+		{"custom url", "https://www.youtube.com/c/RickAstley", Target{Kind: TargetChannel, Channel: "c/RickAstley"}, false},
+		{"user url", "https://www.youtube.com/user/RickAstleyVEVO", Target{Kind: TargetChannel, Channel: "user/RickAstleyVEVO"}, false},
+		{"nav param is not v", "https://www.youtube.com/results?nav=" + id, Target{}, true},
+		{"rev param is not v", "https://www.youtube.com/watch?rev=" + id, Target{}, true},
+		{"other host", "https://example.com/watch?v=" + id, Target{}, true},
+		{"short id", "https://youtu.be/abc", Target{}, true},
+		{"empty", "", Target{}, true},
+		{"plain text", "hello world", Target{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		err   bool
+	}{
+		{"90", 90 * time.Second, false},
+		{"1m30s", 90 * time.Second, false},
+		{"2h3m", 2*time.Hour + 3*time.Minute, false},
+		{"1:30", 90 * time.Second, false},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second, false},
+		{"1.5", 1500 * time.Millisecond, false},
+		{"", 0, true},
+		{"1x", 0, true},
+		{"1:2:3:4", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.input)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v, error %v", tt.input, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFindTargets(t *testing.T) {
+	text := "# comment\n" +
+		"dQw4w9WgXcQ\n" +
+		"M7lc1UVf-VE great song\n" +
+		"see [this](https://youtu.be/jNQXAC9IVRw?t=30). and **https://www.youtube.com/watch?v=9bZkp7q19f0**\n" +
+		"nothing here\n"
+	got := FindTargets(text)
+	want := []string{"dQw4w9WgXcQ", "M7lc1UVf-VE", "jNQXAC9IVRw", "9bZkp7q19f0"}
+	if len(got) != len(want) {
+		t.Fatalf("FindTargets found %d targets, want %d: %+v", len(got), len(want), got)
+	}
+	for i, id := range want {
+		if got[i].VideoId != id {
+			t.Errorf("target %d = %q, want %q", i, got[i].VideoId, id)
+		}
+	}
+	if got[2].Start != 30*time.Second {
+		t.Errorf("target 2 start = %v, want 30s", got[2].Start)
+	}
+}
@@ -0,0 +1,7 @@
+#!/bin/sh
+# Test token provider: logs each request to $TOKEN_LOG and answers with a
+# token derived from the video ID that lives for $TOKEN_TTL seconds.
+input=$(cat)
+printf '%s\n' "$input" >> "$TOKEN_LOG"
+id=$(printf '%s' "$input" | sed -n 's/.*"videoId":"\([^"]*\)".*/\1/p')
+printf '{"poToken":"pot-%s","visitorData":"visitor-%s","ttl":%s}\n' "$id" "$id" "${TOKEN_TTL:-0}"
@@ -0,0 +1,240 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTokenTtl = 6 * time.Hour
+
+type Token struct {
+	PoToken     string    `json:"poToken"`
+	VisitorData string    `json:"visitorData"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+func (t Token) valid() bool {
+	return t.PoToken != "" && time.Now().Before(t.ExpiresAt)
+}
+
+var tokenSource func(videoId string) (Token, error)
+
+var visitor struct {
+	sync.Mutex
+	id string
+}
+
+func SetTokenSource(fn func(videoId string) (Token, error)) {
+	tokenSource = fn
+}
+
+func SetVisitorData(visitorData string) {
+	visitor.Lock()
+	defer visitor.Unlock()
+	visitor.id = visitorData
+}
+
+func currentVisitorData() string {
+	visitor.Lock()
+	defer visitor.Unlock()
+	return visitor.id
+}
+
+func StaticToken(poToken, visitorData string) func(string) (Token, error) {
+	return func(string) (Token, error) {
+		return Token{PoToken: poToken, VisitorData: visitorData}, nil
+	}
+}
+
+func DefaultTokenCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "mpv-yt", "token.json")
+}
+
+type providerRequest struct {
+	VideoId     string `json:"videoId"`
+	VisitorData string `json:"visitorData,omitempty"`
+}
+
+type providerResponse struct {
+	PoToken     string    `json:"poToken"`
+	VisitorData string    `json:"visitorData"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	Ttl         int       `json:"ttl"`
+}
+
+func TokenProvider(command, visitorData, cacheFile string) func(string) (Token, error) {
+	var mu sync.Mutex
+	cached := readTokenCache(cacheFile)
+	return func(videoId string) (Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if tok := cached[videoId]; tok.valid() {
+			return tok, nil
+		}
+		if visitorData == "" {
+			for _, tok := range cached {
+				if tok.valid() && tok.VisitorData != "" {
+					visitorData = tok.VisitorData
+					break
+				}
+			}
+		}
+		tok, err := runTokenProvider(command, providerRequest{VideoId: videoId, VisitorData: visitorData})
+		if err != nil {
+			return Token{}, err
+		}
+		for id, old := range cached {
+			if !old.valid() {
+				delete(cached, id)
+			}
+		}
+		cached[videoId] = tok
+		writeTokenCache(cacheFile, cached)
+		return tok, nil
+	}
+}
+
+func runTokenProvider(command string, req providerRequest) (Token, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return Token{}, err
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return Token{}, err
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Token{}, fmt.Errorf("token provider failed: %w", err)
+	}
+	var resp providerResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return Token{}, fmt.Errorf("token provider returned invalid JSON: %w", err)
+	}
+	if resp.PoToken == "" {
+		return Token{}, errors.New("token provider returned no poToken")
+	}
+	tok := Token{PoToken: resp.PoToken, VisitorData: resp.VisitorData, ExpiresAt: resp.ExpiresAt}
+	if tok.VisitorData == "" {
+		tok.VisitorData = req.VisitorData
+	}
+	if tok.ExpiresAt.IsZero() {
+		ttl := defaultTokenTtl
+		if resp.Ttl > 0 {
+			ttl = time.Duration(resp.Ttl) * time.Second
+		}
+		tok.ExpiresAt = time.Now().Add(ttl)
+	}
+	return tok, nil
+}
+
+func splitCommand(command string) ([]string, error) {
+	if _, err := os.Stat(command); err == nil {
+		return []string{command}, nil
+	}
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, c := range command {
+		switch {
+		case escaped:
+			cur.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote in token provider command")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty token provider command")
+	}
+	return args, nil
+}
+
+func readTokenCache(path string) map[string]Token {
+	tokens := map[string]Token{}
+	if path == "" {
+		return tokens
+	}
+	if raw, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(raw, &tokens)
+	}
+	return tokens
+}
+
+func writeTokenCache(path string, tokens map[string]Token) {
+	if path == "" {
+		return
+	}
+	raw, err := json.Marshal(tokens)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	os.WriteFile(path, raw, 0o600)
+}
+
+func currentToken(videoId string) Token {
+	if tokenSource == nil {
+		return Token{}
+	}
+	tok, err := tokenSource(videoId)
+	if err != nil {
+		logf("token: %v", err)
+		return Token{}
+	}
+	return tok
+}
+
+func withPoToken(streamUrl, poToken string) string {
+	if poToken == "" || streamUrl == "" {
+		return streamUrl
+	}
+	sep := "?"
+	if strings.Contains(streamUrl, "?") {
+		sep = "&"
+	}
+	return streamUrl + sep + "pot=" + url.QueryEscape(poToken)
+}
@@ -0,0 +1,170 @@
+package youtube
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testProvider(t *testing.T) (command string, requests func() []providerRequest) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("token provider script needs sh")
+	}
+	dir := filepath.Join(t.TempDir(), "token provider")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	script, err := os.ReadFile(filepath.Join("testdata", "token_provider.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	command = filepath.Join(dir, "provider.sh")
+	if err := os.WriteFile(command, script, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "requests.log")
+	t.Setenv("TOKEN_LOG", log)
+	return command, func() []providerRequest {
+		raw, _ := os.ReadFile(log)
+		var reqs []providerRequest
+		for _, line := range strings.Fields(string(raw)) {
+			var req providerRequest
+			if err := json.Unmarshal([]byte(line), &req); err != nil {
+				t.Fatalf("provider got invalid JSON %q: %v", line, err)
+			}
+			reqs = append(reqs, req)
+		}
+		return reqs
+	}
+}
+
+func TestTokenProvider(t *testing.T) {
+	command, requests := testProvider(t)
+	t.Setenv("TOKEN_TTL", "3600")
+	cacheFile := filepath.Join(t.TempDir(), "token.json")
+	provider := TokenProvider(command, "CgtWaXNpdG9y", cacheFile)
+
+	tok, err := provider("dQw4w9WgXcQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.PoToken != "pot-dQw4w9WgXcQ" || tok.VisitorData != "visitor-dQw4w9WgXcQ" {
+		t.Errorf("token = %+v", tok)
+	}
+	if until := time.Until(tok.ExpiresAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("token expires in %v, want the 1h ttl", until)
+	}
+	if _, err := provider("dQw4w9WgXcQ"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(requests()); got != 1 {
+		t.Errorf("provider ran %d times for one video, want the cached token", got)
+	}
+
+	other, err := provider("9bZkp7q19f0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.PoToken != "pot-9bZkp7q19f0" {
+		t.Errorf("second video got token %q", other.PoToken)
+	}
+	want := []providerRequest{
+		{VideoId: "dQw4w9WgXcQ", VisitorData: "CgtWaXNpdG9y"},
+		{VideoId: "9bZkp7q19f0", VisitorData: "CgtWaXNpdG9y"},
+	}
+	if got := requests(); !slices.Equal(got, want) {
+		t.Errorf("provider requests = %+v, want %+v", got, want)
+	}
+
+	reloaded := TokenProvider(command, "", cacheFile)
+	if tok, err := reloaded("9bZkp7q19f0"); err != nil || tok.PoToken != "pot-9bZkp7q19f0" {
+		t.Errorf("cached token = %+v, %v", tok, err)
+	}
+	if got := len(requests()); got != 2 {
+		t.Errorf("provider ran again despite the token cache file")
+	}
+}
+
+func TestTokenProviderDefaultTtl(t *testing.T) {
+	command, _ := testProvider(t)
+	tok, err := TokenProvider(command, "", "")("dQw4w9WgXcQ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until := time.Until(tok.ExpiresAt); until < defaultTokenTtl-time.Minute || until > defaultTokenTtl {
+		t.Errorf("token expires in %v, want the default %v", until, defaultTokenTtl)
+	}
+}
+
+func TestTokenOnlyForPlayer(t *testing.T) {
+	command, requests := testProvider(t)
+	savedSource := tokenSource
+	t.Cleanup(func() {
+		SetTokenSource(savedSource)
+		SetVisitorData("")
+	})
+	SetTokenSource(TokenProvider(command, "", ""))
+	SetVisitorData("CgtWaXNpdG9y")
+
+	var bodies []apiRequest
+	fakeApi(t, func(req apiRequest) any {
+		bodies = append(bodies, req)
+		return map[string]any{}
+	})
+	var out any
+	if err := callApi("browse", clientWeb, map[string]any{"browseId": "VLPL123"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(requests()); got != 0 {
+		t.Fatalf("browse request ran the token provider %d times", got)
+	}
+	if err := callApi("player", clientWeb, playerPayload("dQw4w9WgXcQ"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(requests()); got != 1 {
+		t.Errorf("player request ran the token provider %d times, want 1", got)
+	}
+
+	if got := str(bodies[0].Body, "context", "client", "visitorData"); got != "CgtWaXNpdG9y" {
+		t.Errorf("browse visitorData = %q", got)
+	}
+	if _, ok := bodies[0].Body["serviceIntegrityDimensions"]; ok {
+		t.Error("browse request carries a poToken")
+	}
+	if got := str(bodies[1].Body, "serviceIntegrityDimensions", "poToken"); got != "pot-dQw4w9WgXcQ" {
+		t.Errorf("player poToken = %q", got)
+	}
+	if got := str(bodies[1].Body, "context", "client", "visitorData"); got != "visitor-dQw4w9WgXcQ" {
+		t.Errorf("player visitorData = %q", got)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"bgutil-pot --json", []string{"bgutil-pot", "--json"}},
+		{`"/opt/my tools/pot" --port 4416`, []string{"/opt/my tools/pot", "--port", "4416"}},
+		{`node '/home/me/pot server/index.js'`, []string{"node", "/home/me/pot server/index.js"}},
+		{`/opt/my\ tools/pot`, []string{"/opt/my tools/pot"}},
+		{`pot ""`, []string{"pot", ""}},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, %v; want %q", tt.command, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "  ", `pot "unterminated`} {
+		if _, err := splitCommand(bad); err == nil {
+			t.Errorf("splitCommand(%q) succeeded", bad)
+		}
+	}
+}
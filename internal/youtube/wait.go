@@ -0,0 +1,81 @@
+package youtube
+
+import (
+	"errors"
+	"mpy-yt/internal/models"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+var SystemClock Clock = systemClock{}
+
+type Waiter struct {
+	Clock   Clock
+	Fetch   func(videoId string) (*models.PlayerData, error)
+	OnTick  func(data *models.PlayerData, remaining time.Duration)
+	MinPoll time.Duration
+	MaxPoll time.Duration
+	Recheck time.Duration
+}
+
+func NewWaiter(onTick func(data *models.PlayerData, remaining time.Duration)) *Waiter {
+	return &Waiter{
+		Clock:   SystemClock,
+		Fetch:   RefreshPlayerData,
+		OnTick:  onTick,
+		MinPoll: 5 * time.Second,
+		MaxPoll: time.Minute,
+		Recheck: 10 * time.Minute,
+	}
+}
+
+func (w *Waiter) Wait(videoId string, data *models.PlayerData) (*models.PlayerData, error) {
+	backoff := w.MinPoll
+	nextPoll := w.Clock.Now().Add(w.delay(data.ScheduledStart, backoff))
+	for {
+		now := w.Clock.Now()
+		if w.OnTick != nil {
+			remaining := time.Duration(0)
+			if !data.ScheduledStart.IsZero() {
+				remaining = data.ScheduledStart.Sub(now)
+			}
+			w.OnTick(data, remaining)
+		}
+		if now.Before(nextPoll) {
+			<-w.Clock.After(min(time.Second, nextPoll.Sub(now)))
+			continue
+		}
+
+		fresh, err := w.Fetch(videoId)
+		switch {
+		case errors.Is(err, ErrNetwork), errors.Is(err, ErrSchemaChanged):
+			logf("waiting for %s: %v", videoId, err)
+		case err != nil:
+			return nil, err
+		case !fresh.IsUpcoming:
+			return fresh, nil
+		default:
+			data = fresh
+		}
+		if !w.Clock.Now().Before(data.ScheduledStart) {
+			backoff = min(backoff*2, w.MaxPoll)
+		}
+		nextPoll = w.Clock.Now().Add(w.delay(data.ScheduledStart, backoff))
+	}
+}
+
+func (w *Waiter) delay(start time.Time, backoff time.Duration) time.Duration {
+	if until := start.Sub(w.Clock.Now()); until > backoff {
+		return min(until, w.Recheck)
+	}
+	return backoff
+}
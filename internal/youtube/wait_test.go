@@ -0,0 +1,149 @@
+package youtube
+
+import (
+	"errors"
+	"mpy-yt/internal/models"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+type stubFetch struct {
+	clock   *fakeClock
+	start   time.Time
+	results []error
+	polls   []time.Duration
+}
+
+var errLive = errors.New("live")
+
+func (s *stubFetch) fetch(videoId string) (*models.PlayerData, error) {
+	s.polls = append(s.polls, s.clock.now.Sub(s.start))
+	result := s.results[0]
+	if len(s.results) > 1 {
+		s.results = s.results[1:]
+	}
+	switch {
+	case result == errLive:
+		return &models.PlayerData{Title: "live", IsLive: true}, nil
+	case result != nil:
+		return nil, result
+	}
+	return &models.PlayerData{IsUpcoming: true}, nil
+}
+
+func newTestWaiter(results ...error) (*Waiter, *stubFetch) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	stub := &stubFetch{clock: clock, start: clock.now, results: results}
+	w := NewWaiter(nil)
+	w.Clock = clock
+	w.Fetch = stub.fetch
+	return w, stub
+}
+
+func TestWaitBackoff(t *testing.T) {
+	w, stub := newTestWaiter(nil, nil, errLive)
+	data, err := w.Wait("dQw4w9WgXcQ", &models.PlayerData{IsUpcoming: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.IsLive {
+		t.Errorf("Wait returned %+v, want the live data", data)
+	}
+	want := []time.Duration{5 * time.Second, 15 * time.Second, 35 * time.Second}
+	if !slices.Equal(stub.polls, want) {
+		t.Errorf("polls at %v, want %v", stub.polls, want)
+	}
+}
+
+func TestWaitScheduledStart(t *testing.T) {
+	w, stub := newTestWaiter(nil, nil, nil, ErrNetwork, nil, nil, nil, errLive)
+	start := stub.start.Add(25 * time.Minute)
+	fetch := w.Fetch
+	w.Fetch = func(videoId string) (*models.PlayerData, error) {
+		data, err := fetch(videoId)
+		if data != nil && data.IsUpcoming {
+			data.ScheduledStart = start
+		}
+		return data, err
+	}
+
+	ticks := 0
+	w.OnTick = func(data *models.PlayerData, remaining time.Duration) {
+		ticks++
+		if want := data.ScheduledStart.Sub(stub.clock.now); remaining != want {
+			t.Fatalf("tick remaining = %v, want %v", remaining, want)
+		}
+	}
+
+	if _, err := w.Wait("dQw4w9WgXcQ", &models.PlayerData{IsUpcoming: true, ScheduledStart: start}); err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{
+		10 * time.Minute,
+		20 * time.Minute,
+		25 * time.Minute,
+		25*time.Minute + 10*time.Second,
+		25*time.Minute + 30*time.Second,
+		26*time.Minute + 10*time.Second,
+		27*time.Minute + 10*time.Second,
+		28*time.Minute + 10*time.Second,
+	}
+	if !slices.Equal(stub.polls, want) {
+		t.Errorf("polls at %v, want %v", stub.polls, want)
+	}
+	if ticks < int((28 * time.Minute).Seconds()) {
+		t.Errorf("only %d countdown ticks", ticks)
+	}
+}
+
+func TestWaitStopsOnError(t *testing.T) {
+	w, stub := newTestWaiter(nil, ErrRemoved)
+	_, err := w.Wait("dQw4w9WgXcQ", &models.PlayerData{IsUpcoming: true})
+	if !errors.Is(err, ErrRemoved) {
+		t.Errorf("Wait error = %v, want ErrRemoved", err)
+	}
+	if len(stub.polls) != 2 {
+		t.Errorf("polled %d times, want 2", len(stub.polls))
+	}
+}
+
+func TestWaitRetriesServerErrors(t *testing.T) {
+	requests := 0
+	failures := len(activeChain())
+	fakeApi(t, func(req apiRequest) any {
+		requests++
+		if requests <= failures {
+			return http.StatusServiceUnavailable
+		}
+		return map[string]any{
+			"playabilityStatus": map[string]any{"status": "OK"},
+			"videoDetails":      map[string]any{"title": "Premiere", "isLive": true},
+			"streamingData":     map[string]any{"hlsManifestUrl": "https://manifest.googlevideo.com/index.m3u8"},
+		}
+	})
+
+	w, _ := newTestWaiter(nil)
+	w.Fetch = RefreshPlayerData
+	data, err := w.Wait("dQw4w9WgXcQ", &models.PlayerData{IsUpcoming: true})
+	if err != nil {
+		t.Fatalf("Wait gave up on a server error: %v", err)
+	}
+	if !data.IsLive || data.HlsManifestUrl == "" {
+		t.Errorf("Wait returned %+v, want the live stream", data)
+	}
+}
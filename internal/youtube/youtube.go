@@ -1,17 +1,29 @@
 package youtube
 
 import (
-	"encoding/json"
+	"cmp"
 	"errors"
 	"fmt"
+	"mpy-yt/internal/cache"
 	"mpy-yt/internal/models"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var Verbose bool
+
+var playerCache *cache.Store
+
+func SetCache(store *cache.Store) {
+	playerCache = store
+}
+
 var httpClient = &http.Client{
 	Timeout: 10 * time.Second,
 	Transport: &http.Transport{
@@ -37,108 +49,113 @@ var itagQualityMap = [702]string{
 	694: "144p", 695: "240p", 696: "360p", 697: "480p", 698: "720p", 699: "1080p", 700: "1440p", 701: "2160p",
 }
 
-const (
-	apiEndpoint      = "https://www.youtube.com/youtubei/v1/player"
-	thumbnailBaseUrl = "https://img.youtube.com/vi/"
-)
+const thumbnailBaseUrl = "https://img.youtube.com/vi/"
 
-type clientConfig struct {
-	name        string
-	version     string
-	id          string
-	deviceModel string
+type formatRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
 }
 
-var (
-	clientAndroid = clientConfig{"ANDROID", "19.50.42", "3", ""}
-	clientIos     = clientConfig{"IOS", "21.03.2", "5", "iPhone14,3"}
-)
-
 type adaptiveFormat struct {
-	Url           string `json:"url"`
-	Bitrate       int64  `json:"bitrate"`
-	MimeType      string `json:"mimeType"`
-	Itag          int    `json:"itag"`
-	ContentLength string `json:"contentLength"`
-	AudioTrack    *struct {
+	Url             string       `json:"url"`
+	SignatureCipher string       `json:"signatureCipher"`
+	Bitrate         int64        `json:"bitrate"`
+	MimeType        string       `json:"mimeType"`
+	Itag            int          `json:"itag"`
+	ContentLength   string       `json:"contentLength"`
+	Width           int          `json:"width"`
+	Height          int          `json:"height"`
+	Fps             int          `json:"fps"`
+	QualityLabel    string       `json:"qualityLabel"`
+	InitRange       *formatRange `json:"initRange"`
+	IndexRange      *formatRange `json:"indexRange"`
+	AudioSampleRate string       `json:"audioSampleRate"`
+	AudioChannels   int          `json:"audioChannels"`
+	ColorInfo       *struct {
+		Primaries               string `json:"primaries"`
+		TransferCharacteristics string `json:"transferCharacteristics"`
+	} `json:"colorInfo"`
+	AudioTrack *struct {
 		DisplayName    string `json:"displayName"`
 		Id             string `json:"id"`
 		AudioIsDefault bool   `json:"audioIsDefault"`
 	} `json:"audioTrack"`
 }
 
+type captionTrack struct {
+	BaseUrl string `json:"baseUrl"`
+	Name    struct {
+		SimpleText string `json:"simpleText"`
+		Runs       []struct {
+			Text string `json:"text"`
+		} `json:"runs"`
+	} `json:"name"`
+	LanguageCode string `json:"languageCode"`
+	Kind         string `json:"kind"`
+}
+
 type playerApiResponse struct {
 	PlayabilityStatus struct {
-		Status string `json:"status"`
-		Reason string `json:"reason"`
+		Status            string `json:"status"`
+		Reason            string `json:"reason"`
+		LiveStreamability struct {
+			LiveStreamabilityRenderer struct {
+				OfflineSlate struct {
+					LiveStreamOfflineSlateRenderer struct {
+						ScheduledStartTime string `json:"scheduledStartTime"`
+					} `json:"liveStreamOfflineSlateRenderer"`
+				} `json:"offlineSlate"`
+			} `json:"liveStreamabilityRenderer"`
+		} `json:"liveStreamability"`
 	} `json:"playabilityStatus"`
 	VideoDetails struct {
-		Title         string `json:"title"`
-		IsLiveContent bool   `json:"isLiveContent"`
-		Thumbnail     struct {
+		Title            string   `json:"title"`
+		Author           string   `json:"author"`
+		ChannelId        string   `json:"channelId"`
+		IsLiveContent    bool     `json:"isLiveContent"`
+		IsLive           bool     `json:"isLive"`
+		IsUpcoming       bool     `json:"isUpcoming"`
+		IsLiveDvrEnabled bool     `json:"isLiveDvrEnabled"`
+		IsPrivate        bool     `json:"isPrivate"`
+		LengthSeconds    string   `json:"lengthSeconds"`
+		ViewCount        string   `json:"viewCount"`
+		ShortDescription string   `json:"shortDescription"`
+		Keywords         []string `json:"keywords"`
+		Thumbnail        struct {
 			Thumbnails []struct {
 				Url string `json:"url"`
 			} `json:"thumbnails"`
 		} `json:"thumbnail"`
 	} `json:"videoDetails"`
+	Microformat struct {
+		PlayerMicroformatRenderer struct {
+			PublishDate          string `json:"publishDate"`
+			UploadDate           string `json:"uploadDate"`
+			Category             string `json:"category"`
+			LiveBroadcastDetails *struct {
+				StartTimestamp string `json:"startTimestamp"`
+			} `json:"liveBroadcastDetails"`
+		} `json:"playerMicroformatRenderer"`
+	} `json:"microformat"`
+	Captions struct {
+		PlayerCaptionsTracklistRenderer struct {
+			CaptionTracks []captionTrack `json:"captionTracks"`
+		} `json:"playerCaptionsTracklistRenderer"`
+	} `json:"captions"`
 	StreamingData *struct {
+		Formats         []adaptiveFormat `json:"formats"`
 		AdaptiveFormats []adaptiveFormat `json:"adaptiveFormats"`
+		HlsManifestUrl  string           `json:"hlsManifestUrl"`
+		DashManifestUrl string           `json:"dashManifestUrl"`
 	} `json:"streamingData"`
 }
 
 func ExtractVideoId(input string) string {
-	n := len(input)
-	if n == 0 {
-		return ""
-	}
-
-	start, end := 0, n
-	for start < end && input[start] <= ' ' {
-		start++
-	}
-	for end > start && input[end-1] <= ' ' {
-		end--
-	}
-	s := input[start:end]
-	n = len(s)
-
-	if n < 11 {
+	t, err := Parse(input)
+	if err != nil {
 		return ""
 	}
-
-	if n == 11 && isValidId(s) {
-		return s
-	}
-
-	if n > 11 && isValidId(s[:11]) {
-		c := s[11]
-		if c == '&' || c == '?' || c == '/' || c <= ' ' {
-			return s[:11]
-		}
-	}
-
-	patterns := [...]struct {
-		prefix string
-		offset int
-	}{
-		{"v=", 2},
-		{"youtu.be/", 9},
-		{"/shorts/", 8},
-		{"/embed/", 7},
-		{"/live/", 6},
-		{"/v/", 3},
-	}
-
-	for _, p := range patterns {
-		if idx := strings.Index(s, p.prefix); idx != -1 {
-			sub := s[idx+p.offset:]
-			if len(sub) >= 11 && isValidId(sub[:11]) {
-				return sub[:11]
-			}
-		}
-	}
-
-	return ""
+	return t.VideoId
 }
 
 func isValidId(s string) bool {
@@ -156,75 +173,137 @@ func isValidId(s string) bool {
 }
 
 func GetPlayerData(videoId string) (*models.PlayerData, error) {
-	data, err := fetchPlayerData(videoId, clientAndroid)
-	if err != nil {
-		errLower := strings.ToLower(err.Error())
-		if strings.Contains(errLower, "login_required") || strings.Contains(errLower, "age") {
-			return fetchPlayerData(videoId, clientIos)
+	if playerCache != nil {
+		for _, key := range activeChain() {
+			if data, ok := playerCache.Get(cacheKey(videoId, key)); ok {
+				logf("using cached data for %s from client %s", videoId, key)
+				return data, nil
+			}
 		}
+	}
+
+	nextCh := make(chan any, 1)
+	go func() {
+		nextCh <- fetchWatchNext(videoId)
+	}()
+
+	data, err := resolvePlayerData(videoId)
+	if err != nil {
 		return nil, err
 	}
+
+	next := <-nextCh
+	if chapters := parseChapters(next); len(chapters) > 0 {
+		data.Chapters = chapters
+	}
+	if data.PublishDate == "" {
+		data.PublishDate = publishedText(next)
+	}
+	data.Heatmap, data.MostReplayed = parseHeatmap(next)
+	if playerCache != nil {
+		if err := playerCache.Put(cacheKey(videoId, data.Client), data); err != nil {
+			logf("cache: %v", err)
+		}
+	}
 	return data, nil
 }
 
-func fetchPlayerData(videoId string, cfg clientConfig) (*models.PlayerData, error) {
-	var b strings.Builder
-	b.Grow(400)
-
-	b.WriteString(`{"context":{"client":{"clientName":"`)
-	b.WriteString(cfg.name)
-	b.WriteString(`","clientVersion":"`)
-	b.WriteString(cfg.version)
-	if cfg.deviceModel != "" {
-		b.WriteString(`","deviceModel":"`)
-		b.WriteString(cfg.deviceModel)
-	}
-	b.WriteString(`","hl":"en","gl":"US"},"user":{"lockedSafetyMode":false}},"videoId":"`)
-	b.WriteString(videoId)
-	b.WriteString(`","contentCheckOk":true,"racyCheckOk":true}`)
-
-	req, _ := http.NewRequest(http.MethodPost, apiEndpoint, strings.NewReader(b.String()))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Youtube-Client-Name", cfg.id)
-	req.Header.Set("X-Youtube-Client-Version", cfg.version)
-
-	resp, err := httpClient.Do(req)
+func cacheKey(videoId, client string) cache.Key {
+	return cache.Key{VideoId: videoId, Client: client, Hl: hl, Gl: gl, Auth: cookieJar != nil}
+}
+
+func RefreshPlayerData(videoId string) (*models.PlayerData, error) {
+	data, err := resolvePlayerData(videoId)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("api request failed: %d", resp.StatusCode)
+	if playerCache != nil {
+		if err := playerCache.Put(cacheKey(videoId, data.Client), data); err != nil {
+			logf("cache: %v", err)
+		}
 	}
+	return data, nil
+}
 
-	var apiResp playerApiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
+func scheduledStart(resp *playerApiResponse) time.Time {
+	slate := resp.PlayabilityStatus.LiveStreamability.LiveStreamabilityRenderer.OfflineSlate.LiveStreamOfflineSlateRenderer
+	if secs, err := strconv.ParseInt(slate.ScheduledStartTime, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0)
 	}
+	if live := resp.Microformat.PlayerMicroformatRenderer.LiveBroadcastDetails; live != nil {
+		if t, err := time.Parse(time.RFC3339, live.StartTimestamp); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
 
-	if apiResp.PlayabilityStatus.Status != "OK" {
-		msg := apiResp.PlayabilityStatus.Reason
-		if msg == "" {
-			msg = apiResp.PlayabilityStatus.Status
+func publishedText(next any) string {
+	var published string
+	walk(next, func(key string, val any) bool {
+		if published != "" {
+			return false
 		}
-		if msg == "" {
-			msg = "video is unplayable"
+		if key != "videoPrimaryInfoRenderer" {
+			return true
 		}
-		return nil, errors.New(msg)
-	}
+		published = text(dig(val, "dateText"))
+		return false
+	})
+	return published
+}
 
-	if apiResp.StreamingData == nil || apiResp.VideoDetails.Title == "" {
-		return nil, errors.New("incomplete video data received from API")
+func resolvePlayerData(videoId string) (*models.PlayerData, error) {
+	var firstPlayability, lastErr error
+	needsAuth := false
+	for _, key := range activeChain() {
+		if authOnly(key) && !needsAuth {
+			continue
+		}
+		cfg := clients[key]
+		data, err := fetchPlayerData(videoId, cfg)
+		if err == nil {
+			logf("resolved %s with client %s", videoId, key)
+			data.Client = key
+			return data, nil
+		}
+		logf("client %s failed for %s: %v", key, videoId, err)
+		lastErr = err
+
+		var pe *PlayabilityError
+		if errors.As(err, &pe) {
+			if firstPlayability == nil {
+				firstPlayability = err
+			}
+			if !pe.retryable() {
+				return nil, err
+			}
+			needsAuth = needsAuth || pe.needsAuth()
+			continue
+		}
+		var ne net.Error
+		if errors.As(err, &ne) {
+			return nil, err
+		}
+	}
+	if firstPlayability != nil {
+		return nil, firstPlayability
 	}
+	return nil, lastErr
+}
 
-	if apiResp.VideoDetails.IsLiveContent {
-		return nil, errors.New("live streams are not supported")
+func playerPayload(videoId string) map[string]any {
+	return map[string]any{
+		"videoId":        videoId,
+		"contentCheckOk": true,
+		"racyCheckOk":    true,
 	}
+}
 
-	videos, audios := parseStreams(apiResp.StreamingData.AdaptiveFormats)
-	if len(audios) == 0 {
-		return nil, errors.New("no audio streams available for this video")
+func fetchPlayerData(videoId string, cfg clientConfig) (*models.PlayerData, error) {
+	var apiResp playerApiResponse
+	if err := callApi("player", cfg, playerPayload(videoId), &apiResp); err != nil {
+		return nil, err
 	}
 
 	thumbUrl := ""
@@ -233,13 +312,132 @@ func fetchPlayerData(videoId string, cfg clientConfig) (*models.PlayerData, erro
 	} else {
 		thumbUrl = thumbnailBaseUrl + videoId + "/maxresdefault.jpg"
 	}
-
-	return &models.PlayerData{
-		Title:        strings.TrimSpace(apiResp.VideoDetails.Title),
+	details := apiResp.VideoDetails
+	micro := apiResp.Microformat.PlayerMicroformatRenderer
+	views, _ := strconv.ParseInt(details.ViewCount, 10, 64)
+	published := micro.PublishDate
+	if published == "" {
+		published = micro.UploadDate
+	}
+	data := &models.PlayerData{
+		Title:        strings.TrimSpace(details.Title),
+		Author:       details.Author,
+		ChannelId:    details.ChannelId,
+		ViewCount:    views,
+		Description:  details.ShortDescription,
+		Keywords:     details.Keywords,
+		IsPrivate:    details.IsPrivate,
+		PublishDate:  published,
+		Category:     micro.Category,
 		ThumbnailUrl: thumbUrl,
-		Videos:       videos,
-		Audios:       audios,
-	}, nil
+	}
+
+	if details.IsUpcoming || apiResp.PlayabilityStatus.Status == "LIVE_STREAM_OFFLINE" {
+		data.IsUpcoming = true
+		data.ScheduledStart = scheduledStart(&apiResp)
+		return data, nil
+	}
+
+	if apiResp.PlayabilityStatus.Status != "OK" {
+		pe := &PlayabilityError{
+			Status: apiResp.PlayabilityStatus.Status,
+			Reason: apiResp.PlayabilityStatus.Reason,
+		}
+		if pe.Reason != "" && !strings.HasPrefix(cmp.Or(cfg.hl, hl), "en") {
+			pe.englishReason = englishReason(videoId, cfg)
+		}
+		return nil, pe
+	}
+
+	if apiResp.StreamingData == nil || details.Title == "" {
+		return nil, fmt.Errorf("%w: incomplete video data received from API", ErrSchemaChanged)
+	}
+
+	if details.IsLive {
+		sd := apiResp.StreamingData
+		if sd.HlsManifestUrl == "" && sd.DashManifestUrl == "" {
+			return nil, errors.New("no manifest available for this live stream")
+		}
+		data.IsLive = true
+		data.IsLiveDvr = details.IsLiveDvrEnabled
+		data.HlsManifestUrl = sd.HlsManifestUrl
+		data.DashManifestUrl = sd.DashManifestUrl
+		return data, nil
+	}
+
+	secs, _ := strconv.ParseInt(details.LengthSeconds, 10, 64)
+	data.Duration = time.Duration(secs) * time.Second
+
+	data.Videos, data.Audios = parseStreams(apiResp.StreamingData.AdaptiveFormats)
+	data.Muxed = parseMuxed(apiResp.StreamingData.Formats)
+	ciphered := 0
+	for _, formats := range [][]adaptiveFormat{apiResp.StreamingData.AdaptiveFormats, apiResp.StreamingData.Formats} {
+		for i := range formats {
+			if formats[i].Url == "" && formats[i].SignatureCipher != "" {
+				ciphered++
+			}
+		}
+	}
+	if ciphered > 0 {
+		logf("client %s: skipped %d formats with signatureCipher, deciphering is not supported", cfg.key, ciphered)
+	}
+	if len(data.Audios) == 0 && len(data.Muxed) == 0 && apiResp.StreamingData.HlsManifestUrl != "" {
+		logf("client %s: no direct streams, using the HLS manifest", cfg.key)
+		data.Videos = nil
+		data.HlsManifestUrl = apiResp.StreamingData.HlsManifestUrl
+	}
+	if len(data.Audios) == 0 && len(data.Muxed) == 0 && data.HlsManifestUrl == "" {
+		if ciphered > 0 {
+			return nil, fmt.Errorf("client %s only returned signature-protected streams, which are not supported", cfg.key)
+		}
+		return nil, errors.New("no audio streams available for this video")
+	}
+	if pot := currentToken(videoId).PoToken; pot != "" {
+		for i := range data.Videos {
+			data.Videos[i].Url = withPoToken(data.Videos[i].Url, pot)
+		}
+		for i := range data.Audios {
+			data.Audios[i].Url = withPoToken(data.Audios[i].Url, pot)
+		}
+		for i := range data.Muxed {
+			data.Muxed[i].Url = withPoToken(data.Muxed[i].Url, pot)
+		}
+	}
+
+	data.Subtitles = parseCaptions(apiResp.Captions.PlayerCaptionsTracklistRenderer.CaptionTracks)
+	data.Chapters = parseDescriptionChapters(details.ShortDescription, data.Duration)
+	return data, nil
+}
+
+func parseCaptions(tracks []captionTrack) []models.SubtitleTrack {
+	subs := make([]models.SubtitleTrack, 0, len(tracks))
+	for _, t := range tracks {
+		u, err := url.Parse(t.BaseUrl)
+		if err != nil || t.BaseUrl == "" {
+			continue
+		}
+		q := u.Query()
+		q.Set("fmt", "json3")
+		u.RawQuery = q.Encode()
+		if !u.IsAbs() {
+			u.Scheme, u.Host = "https", "www.youtube.com"
+		}
+
+		name := t.Name.SimpleText
+		if name == "" && len(t.Name.Runs) > 0 {
+			name = t.Name.Runs[0].Text
+		}
+		if name == "" {
+			name = t.LanguageCode
+		}
+		subs = append(subs, models.SubtitleTrack{
+			Language:        t.LanguageCode,
+			Name:            name,
+			IsAutoGenerated: t.Kind == "asr",
+			Url:             u.String(),
+		})
+	}
+	return subs
 }
 
 func parseStreams(formats []adaptiveFormat) ([]models.VideoStream, []models.AudioStream) {
@@ -257,20 +455,15 @@ func parseStreams(formats []adaptiveFormat) ([]models.VideoStream, []models.Audi
 			continue
 		}
 
-		size, _ := strconv.ParseInt(f.ContentLength, 10, 64)
-
 		if mime[0] == 'v' && mime[4] == 'o' {
-			if f.Itag < 0 || f.Itag >= len(itagQualityMap) {
-				continue
-			}
-			quality := itagQualityMap[f.Itag]
-			if quality == "" {
+			v, ok := videoStream(f)
+			if !ok {
 				continue
 			}
 
 			found := -1
 			for j := range videos {
-				if videos[j].Quality == quality {
+				if videos[j].Quality == v.Quality && videos[j].Codec == v.Codec && videos[j].Fps == v.Fps && videos[j].Hdr == v.Hdr {
 					found = j
 					break
 				}
@@ -278,15 +471,10 @@ func parseStreams(formats []adaptiveFormat) ([]models.VideoStream, []models.Audi
 
 			if found != -1 {
 				if f.Bitrate > videos[found].Bitrate {
-					videos[found].Url = f.Url
-					videos[found].Bitrate = f.Bitrate
-					videos[found].Size = size
+					videos[found] = v
 				}
 			} else {
-				videos = append(videos, models.VideoStream{
-					Stream:  models.Stream{Url: f.Url, Bitrate: f.Bitrate, Size: size},
-					Quality: quality,
-				})
+				videos = append(videos, v)
 			}
 
 		} else if mime[0] == 'a' && mime[4] == 'o' {
@@ -318,28 +506,27 @@ func parseStreams(formats []adaptiveFormat) ([]models.VideoStream, []models.Audi
 				}
 			}
 
+			sampleRate, _ := strconv.Atoi(f.AudioSampleRate)
+			a := models.AudioStream{
+				Stream:     newStream(f),
+				Language:   langCode,
+				Name:       displayName,
+				IsDefault:  isDefault,
+				SampleRate: sampleRate,
+				Channels:   f.AudioChannels,
+			}
+
 			if found != -1 {
 				if f.Bitrate > audios[found].Bitrate {
-					audios[found].Url = f.Url
-					audios[found].Bitrate = f.Bitrate
-					audios[found].Size = size
-					audios[found].Name = displayName
-					audios[found].IsDefault = isDefault
+					audios[found] = a
 				}
 			} else {
-				audios = append(audios, models.AudioStream{
-					Stream:    models.Stream{Url: f.Url, Bitrate: f.Bitrate, Size: size},
-					Language:  langCode,
-					Name:      displayName,
-					IsDefault: isDefault,
-				})
+				audios = append(audios, a)
 			}
 		}
 	}
 
-	slices.SortFunc(videos, func(a, b models.VideoStream) int {
-		return int(b.Bitrate - a.Bitrate)
-	})
+	slices.SortFunc(videos, compareVideos)
 
 	slices.SortFunc(audios, func(a, b models.AudioStream) int {
 		if a.IsDefault != b.IsDefault {
@@ -353,3 +540,155 @@ func parseStreams(formats []adaptiveFormat) ([]models.VideoStream, []models.Audi
 
 	return videos, audios
 }
+
+var standardHeights = [...]int{144, 240, 360, 480, 720, 1080, 1440, 2160, 4320}
+
+func parseMuxed(formats []adaptiveFormat) []models.MuxedStream {
+	muxed := make([]models.MuxedStream, 0, len(formats))
+	for i := range formats {
+		f := &formats[i]
+		if f.Url == "" || !strings.HasPrefix(f.MimeType, "video/") {
+			continue
+		}
+		v, ok := videoStream(f)
+		if !ok {
+			continue
+		}
+		muxed = append(muxed, models.MuxedStream{VideoStream: v})
+	}
+	slices.SortFunc(muxed, func(a, b models.MuxedStream) int {
+		return compareVideos(a.VideoStream, b.VideoStream)
+	})
+	return muxed
+}
+
+func newStream(f *adaptiveFormat) models.Stream {
+	size, _ := strconv.ParseInt(f.ContentLength, 10, 64)
+	id := strconv.Itoa(f.Itag)
+	if f.AudioTrack != nil && f.AudioTrack.Id != "" {
+		id += "." + f.AudioTrack.Id
+	}
+	return models.Stream{
+		Id:         id,
+		Url:        f.Url,
+		MimeType:   f.MimeType,
+		Bitrate:    f.Bitrate,
+		Size:       size,
+		Itag:       f.Itag,
+		InitRange:  byteRange(f.InitRange),
+		IndexRange: byteRange(f.IndexRange),
+	}
+}
+
+func byteRange(r *formatRange) models.ByteRange {
+	if r == nil {
+		return models.ByteRange{}
+	}
+	start, err1 := strconv.ParseInt(r.Start, 10, 64)
+	end, err2 := strconv.ParseInt(r.End, 10, 64)
+	if err1 != nil || err2 != nil || end < start {
+		return models.ByteRange{}
+	}
+	return models.ByteRange{Start: start, End: end}
+}
+
+func videoStream(f *adaptiveFormat) (models.VideoStream, bool) {
+	quality := videoQuality(f)
+	if quality == "" {
+		return models.VideoStream{}, false
+	}
+	v := models.VideoStream{
+		Stream:    newStream(f),
+		Quality:   quality,
+		Width:     f.Width,
+		Height:    f.Height,
+		Fps:       f.Fps,
+		Codec:     codecFamily(f.MimeType),
+		Container: container(f.MimeType),
+	}
+	if c := f.ColorInfo; c != nil {
+		v.ColorPrimaries = colorName(c.Primaries, "COLOR_PRIMARIES_")
+		v.ColorTransfer = colorName(c.TransferCharacteristics, "COLOR_TRANSFER_CHARACTERISTICS_")
+	}
+	v.Hdr = v.ColorTransfer == "smptest2084" || v.ColorTransfer == "arib_std_b67" || strings.Contains(f.QualityLabel, "HDR")
+	return v, true
+}
+
+func compareVideos(a, b models.VideoStream) int {
+	if a.Height != b.Height {
+		return b.Height - a.Height
+	}
+	if a.Fps != b.Fps {
+		return b.Fps - a.Fps
+	}
+	return int(b.Bitrate - a.Bitrate)
+}
+
+func videoQuality(f *adaptiveFormat) string {
+	if label := f.QualityLabel; label != "" {
+		if end := strings.IndexByte(label, 'p'); end > 0 {
+			if _, err := strconv.Atoi(label[:end]); err == nil {
+				return label[:end+1]
+			}
+		}
+	}
+	if f.Width > 0 && f.Height > 0 {
+		short, long := min(f.Width, f.Height), max(f.Width, f.Height)
+		class := max(short, long*9/16)
+		best := standardHeights[0]
+		for _, h := range standardHeights {
+			if abs(h-class) < abs(best-class) {
+				best = h
+			}
+		}
+		return strconv.Itoa(best) + "p"
+	}
+	if f.Itag >= 0 && f.Itag < len(itagQualityMap) {
+		return itagQualityMap[f.Itag]
+	}
+	return ""
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func codecFamily(mime string) string {
+	_, params, _ := strings.Cut(mime, "codecs=")
+	codec := strings.ToLower(strings.Trim(params, `" `))
+	switch {
+	case strings.HasPrefix(codec, "av01"):
+		return "av1"
+	case strings.HasPrefix(codec, "vp09"), strings.HasPrefix(codec, "vp9"):
+		return "vp9"
+	case strings.HasPrefix(codec, "avc1"):
+		return "h264"
+	case strings.HasPrefix(codec, "hev1"), strings.HasPrefix(codec, "hvc1"):
+		return "h265"
+	case strings.HasPrefix(codec, "vp8"):
+		return "vp8"
+	}
+	if dot := strings.IndexByte(codec, '.'); dot > 0 {
+		return codec[:dot]
+	}
+	return codec
+}
+
+func container(mime string) string {
+	typ, _, _ := strings.Cut(mime, ";")
+	_, sub, _ := strings.Cut(typ, "/")
+	return strings.TrimSpace(sub)
+}
+
+func colorName(v, prefix string) string {
+	return strings.ToLower(strings.TrimPrefix(v, prefix))
+}
+
+func logf(format string, args ...any) {
+	if Verbose {
+		fmt.Fprintf(os.Stderr, "[youtube] "+format+"\n", args...)
+	}
+}
@@ -1,62 +1,467 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"mpy-yt/internal/cache"
+	"mpy-yt/internal/config"
+	"mpy-yt/internal/cookies"
+	"mpy-yt/internal/models"
 	"mpy-yt/internal/mpv"
+	"mpy-yt/internal/proxy"
+	"mpy-yt/internal/sponsorblock"
 	"mpy-yt/internal/ui"
 	"mpy-yt/internal/youtube"
 	"os"
+	"slices"
+	"strings"
+	"time"
 )
 
 func main() {
-	var quality, lang string
-	var audioOnly bool
-	flag.StringVar(&quality, "q", "", "Stream quality")
-	flag.StringVar(&quality, "quality", "", "Stream quality")
-	flag.StringVar(&lang, "l", "", "Audio language")
-	flag.StringVar(&lang, "language", "", "Audio language")
-	flag.BoolVar(&audioOnly, "a", false, "Play audio only")
-	flag.BoolVar(&audioOnly, "audio", false, "Play audio only")
+	cfg, err := config.Load(config.DefaultFile())
+	if err != nil {
+		fail(err)
+	}
+	var prefs preferences
+	var items, subLangs, sbCats, clientChain, clientsFile, codecs string
+	var shuffle, reverse bool
+	var filter youtube.SearchFilter
+	var tab string
+	var poToken, visitorData, tokenProvider, cookieFile string
+	var noCache bool
+	var startFlag, endFlag, batchFile string
+	envHl, envGl := config.EnvLocale()
+	hl, gl := cmp.Or(cfg.Hl, envHl, "en"), cmp.Or(cfg.Gl, envGl, "US")
+	flag.StringVar(&prefs.stream.Quality, "q", "", "Stream quality")
+	flag.StringVar(&prefs.stream.Quality, "quality", "", "Stream quality")
+	flag.StringVar(&prefs.stream.Language, "l", "", "Audio language")
+	flag.StringVar(&prefs.stream.Language, "language", "", "Audio language")
+	flag.BoolVar(&prefs.stream.AudioOnly, "a", false, "Play audio only")
+	flag.BoolVar(&prefs.stream.AudioOnly, "audio", false, "Play audio only")
+	flag.StringVar(&codecs, "codec", "", "Preferred video codecs in order, e.g. av1,vp9,h264")
+	flag.IntVar(&prefs.stream.Fps, "prefer-fps", 0, "Preferred video frame rate, e.g. 30 or 60")
+	flag.BoolVar(&prefs.stream.NoHdr, "no-hdr", false, "Never select HDR video streams")
+	flag.BoolVar(&prefs.dash, "dash", false, "Play through a local DASH manifest to allow switching quality in mpv")
+	flag.BoolVar(&prefs.liveDirect, "live-direct", false, "Play live streams without the local proxy")
+	flag.BoolVar(&prefs.wait, "wait", false, "Wait for upcoming streams and premieres to start, then play them")
+	flag.StringVar(&subLangs, "sub-lang", "", "Subtitle languages in order of preference, e.g. en,de or all")
+	flag.BoolVar(&prefs.noAutoSubs, "no-auto-subs", false, "Never fall back to auto-generated subtitles")
+	flag.StringVar(&prefs.sbMode, "sponsorblock", "", "SponsorBlock segment handling: mark or skip")
+	flag.StringVar(&sbCats, "sb-categories", strings.Join(sponsorblock.DefaultCategories, ","), "SponsorBlock categories")
+	flag.StringVar(&prefs.sbApi, "sb-api", sponsorblock.DefaultApiUrl, "SponsorBlock API base URL")
+	flag.StringVar(&clientChain, "clients", "", "Innertube clients to try in order, e.g. ANDROID,IOS,TV_EMBEDDED")
+	flag.StringVar(&clientsFile, "clients-file", youtube.DefaultClientsFile(), "JSON file with innertube client definitions")
+	flag.StringVar(&poToken, "po-token", cfg.PoToken, "Proof-of-origin token sent with player requests")
+	flag.StringVar(&visitorData, "visitor-data", cfg.VisitorData, "visitorData value sent with innertube requests")
+	flag.StringVar(&tokenProvider, "token-provider", cfg.TokenProvider, "Command that prints a JSON PO token, used when --po-token is unset")
+	flag.StringVar(&cookieFile, "cookies", cfg.Cookies, "Netscape cookies.txt file for signed-in requests")
+	flag.StringVar(&hl, "hl", hl, "Interface language for YouTube requests and the default audio track")
+	flag.StringVar(&gl, "gl", gl, "Region for YouTube requests")
+	flag.BoolVar(&noCache, "no-cache", false, "Do not read or write cached video data")
+	flag.BoolVar(&ui.Heatmap, "heatmap", false, "Show a sparkline of the most replayed parts in the video info")
+	flag.BoolVar(&youtube.Verbose, "v", false, "Print diagnostic messages")
+	flag.BoolVar(&youtube.Verbose, "verbose", false, "Print diagnostic messages")
+	flag.StringVar(&startFlag, "start", "", "Start position for a single video, e.g. 90, 1m30s or 1:30")
+	flag.StringVar(&endFlag, "end", "", "End position for a single video, same syntax as --start")
+	flag.StringVar(&batchFile, "batch-file", "", "File with YouTube links to queue, one per line or anywhere in the text")
+	flag.StringVar(&items, "playlist-items", "", "Playlist entries to play, e.g. 1-5,9")
+	flag.BoolVar(&shuffle, "shuffle", false, "Play playlist or queue entries in random order")
+	flag.BoolVar(&reverse, "reverse", false, "Play playlist or queue entries in reverse order")
+	flag.StringVar(&filter.Duration, "duration", "", "Search filter: short, medium or long")
+	flag.StringVar(&filter.Date, "date", "", "Search filter: hour, today, week, month or year")
+	flag.StringVar(&filter.Type, "type", "", "Search filter: video, channel, playlist or movie")
+	flag.StringVar(&tab, "tab", "videos", "Channel tab: videos, shorts, live or playlists")
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] <identifier>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <identifier|-> [identifier...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s search [options] <query>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s channel [options] <channel>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s cache clean\n", os.Args[0])
 		flag.PrintDefaults()
+		fmt.Fprint(os.Stderr, exitStatusHelp)
 	}
 	flag.Parse()
+	command := ""
+	if args := flag.Args(); len(args) > 0 && (args[0] == "search" || args[0] == "channel" || args[0] == "cache") {
+		command = args[0]
+		flag.CommandLine.Parse(args[1:])
+	}
+	if codecs != "" {
+		prefs.stream.Codecs = strings.Split(codecs, ",")
+	}
+	if subLangs != "" {
+		prefs.subLangs = strings.Split(subLangs, ",")
+	}
+	if prefs.sbMode != "" && prefs.sbMode != "mark" && prefs.sbMode != "skip" {
+		fmt.Fprintf(os.Stderr, "Error: Invalid SponsorBlock mode: '%s'\n", prefs.sbMode)
+		os.Exit(exitUsage)
+	}
+	prefs.sbCats = strings.Split(sbCats, ",")
+	startAt, endAt := parseTimeFlag("start", startFlag), parseTimeFlag("end", endFlag)
+	if clientsFile != "" {
+		if err := youtube.LoadClients(clientsFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fail(err)
+		}
+	}
+	if clientChain != "" {
+		if err := youtube.SetClientChain(strings.Split(clientChain, ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+	if err := youtube.SetLocale(hl, gl); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	prefs.stream.DefaultLanguage = hl
+	if cookieFile != "" {
+		jar, err := cookies.Load(cookieFile)
+		if err != nil {
+			fail(err)
+		}
+		youtube.SetCookies(jar)
+		proxy.SetCookies(jar)
+	}
+	youtube.SetVisitorData(visitorData)
+	if tokenProvider != "" && poToken == "" {
+		youtube.SetTokenSource(youtube.TokenProvider(tokenProvider, visitorData, youtube.DefaultTokenCacheFile()))
+	} else if poToken != "" || visitorData != "" {
+		youtube.SetTokenSource(youtube.StaticToken(poToken, visitorData))
+	}
+	store := cache.New(cache.DefaultDir())
+	if !noCache {
+		youtube.SetCache(store)
+	}
 	args := flag.Args()
-	var identifier string
-	if len(args) > 0 {
-		identifier = args[0]
-		if youtube.ExtractVideoId(identifier) == "" {
-			fmt.Fprintf(os.Stderr, "Error: Invalid YouTube URL or Video ID: '%s'\n", identifier)
-			os.Exit(1)
+	if command == "cache" {
+		if len(args) != 1 || args[0] != "clean" {
+			flag.Usage()
+			os.Exit(exitUsage)
+		}
+		removed, err := store.Clean()
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("Removed %d cached entries.\n", removed)
+		return
+	}
+	if command == "search" {
+		if err := runSearch(strings.Join(args, " "), filter, &prefs); err != nil {
+			fail(err)
 		}
+		return
 	}
-	id := identifier
-	if id == "" {
-		id = ui.GetIdentifierFromInput()
+	if command == "channel" {
+		if len(args) == 0 || youtube.ExtractChannel(args[0]) == "" {
+			fmt.Fprintln(os.Stderr, "Error: No valid channel provided.")
+			os.Exit(exitUsage)
+		}
+		if err := runChannel(youtube.ExtractChannel(args[0]), tab, &prefs); err != nil {
+			fail(err)
+		}
+		return
 	}
-	if id == "" {
-		fmt.Fprintln(os.Stderr, "Error: No identifier provided.")
-		os.Exit(1)
+	targets := collectTargets(args, batchFile)
+	if len(targets) == 0 {
+		id := ui.GetIdentifierFromInput()
+		if id == "" {
+			fmt.Fprintln(os.Stderr, "Error: No identifier provided.")
+			os.Exit(exitUsage)
+		}
+		t, err := youtube.Parse(id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid YouTube URL or Video ID: '%s'\n", id)
+			os.Exit(exitUsage)
+		}
+		targets = append(targets, t)
 	}
-	videoId := youtube.ExtractVideoId(id)
-	if videoId == "" {
-		fmt.Fprintf(os.Stderr, "Error: Invalid YouTube URL or Video ID: '%s'\n", id)
-		os.Exit(1)
+	if len(targets) > 1 {
+		if err := checkQueueFlags(items, startFlag, endFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		items, failures := buildQueue(targets, shuffle, reverse)
+		if err := playQueue(items, failures, &prefs); err != nil {
+			os.Exit(classifyCode(err))
+		}
+		return
 	}
-	playerData, err := youtube.GetPlayerData(videoId)
+	target := targets[0]
+	if target.Kind == youtube.TargetClip {
+		if target, err = youtube.ResolveClip(target.ClipId); err != nil {
+			fail(err)
+		}
+	}
+	if target.Kind == youtube.TargetAlbum {
+		if target, err = youtube.ResolveAlbum(target.AlbumId); err != nil {
+			fail(err)
+		}
+	}
+	if startFlag != "" {
+		target.Start = startAt
+	}
+	if endFlag != "" {
+		target.End = endAt
+	}
+	switch target.Kind {
+	case youtube.TargetPlaylist, youtube.TargetPlaylistVideo:
+		playlist, err := youtube.GetPlaylist(target.PlaylistId, target.VideoId)
+		if err != nil && target.Kind == youtube.TargetPlaylistVideo {
+			fmt.Fprintf(os.Stderr, "Could not load playlist %s (%v), playing the video only.\n", target.PlaylistId, err)
+			if _, err := play(target, &prefs); err != nil {
+				fail(err)
+			}
+			return
+		}
+		if err != nil {
+			fail(err)
+		}
+		entries, err := selectEntries(playlist.Entries, target, items, shuffle, reverse)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		if err := playEntries(entries, &prefs, target); err != nil {
+			os.Exit(classifyCode(err))
+		}
+	case youtube.TargetChannel:
+		if err := runChannel(target.Channel, tab, &prefs); err != nil {
+			fail(err)
+		}
+	default:
+		if _, err := play(target, &prefs); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func parseTimeFlag(name, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := youtube.ParseTime(value)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error: Invalid --%s value: '%s'\n", name, value)
+		os.Exit(exitUsage)
+	}
+	return d
+}
+
+func collectTargets(args []string, batchFile string) []youtube.Target {
+	var targets []youtube.Target
+	fromStdin := false
+	for _, arg := range args {
+		if arg == "-" {
+			raw, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fail(err)
+			}
+			targets = append(targets, youtube.FindTargets(string(raw))...)
+			fromStdin = true
+			continue
+		}
+		found := youtube.FindTargets(arg)
+		if len(found) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: Invalid YouTube URL or Video ID: '%s'\n", arg)
+			os.Exit(exitUsage)
+		}
+		targets = append(targets, found...)
 	}
-	video, audio := ui.GetStreamSelection(playerData, quality, lang, audioOnly)
-	if audio == nil {
-		os.Exit(0)
+	if batchFile != "" {
+		raw, err := os.ReadFile(batchFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		targets = append(targets, youtube.FindTargets(string(raw))...)
 	}
-	if err := mpv.Launch(playerData.Title, playerData.ThumbnailUrl, video, audio); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if fromStdin {
+		ui.ReopenTerminal()
+	}
+	if (fromStdin || batchFile != "") && len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No YouTube links found in the input.")
+		os.Exit(exitUsage)
+	}
+	return targets
+}
+
+type preferences struct {
+	stream     ui.Preferences
+	dash       bool
+	liveDirect bool
+	wait       bool
+	subLangs   []string
+	noAutoSubs bool
+	sbMode     string
+	sbApi      string
+	sbCats     []string
+}
+
+type resolved struct {
+	target   youtube.Target
+	data     *models.PlayerData
+	segments <-chan []models.Segment
+	err      error
+}
+
+func resolve(target youtube.Target, prefs *preferences) resolved {
+	if target.Kind == youtube.TargetClip {
+		clip, err := youtube.ResolveClip(target.ClipId)
+		if err != nil {
+			return resolved{target: target, err: err}
+		}
+		target = clip
+	}
+	segments := fetchSegments(target.VideoId, prefs)
+	data, err := youtube.GetPlayerData(target.VideoId)
+	if err == nil && target.Music && !data.IsLive && !data.IsUpcoming {
+		data.Music, _ = youtube.GetMusicInfo(target.VideoId, target.PlaylistId)
+	}
+	return resolved{target: target, data: data, segments: segments, err: err}
+}
+
+func play(target youtube.Target, prefs *preferences) (bool, error) {
+	return playResolved(resolve(target, prefs), prefs)
+}
+
+func playResolved(r resolved, prefs *preferences) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	target, playerData, segments := r.target, r.data, r.segments
+	if playerData.IsUpcoming {
+		if !prefs.wait {
+			return false, fmt.Errorf("'%s': %w", playerData.Title, youtube.ErrUpcoming)
+		}
+		ui.ShowInfo(playerData)
+		data, err := youtube.NewWaiter(ui.ShowCountdown).Wait(target.VideoId, playerData)
+		fmt.Println()
+		if err != nil {
+			return false, err
+		}
+		playerData = data
+	}
+	if playerData.IsLive {
+		ui.ShowLive(playerData)
+		if err := mpv.LaunchLive(playerData, prefs.stream.AudioOnly, prefs.liveDirect); err != nil {
+			return false, err
+		}
+		fmt.Print("\033[H\033[2J")
+		return true, nil
+	}
+	var sel models.Selection
+	if len(playerData.Audios) == 0 && len(playerData.Muxed) == 0 && playerData.HlsManifestUrl != "" {
+		ui.ShowInfo(playerData)
+		fmt.Println("Only an HLS manifest is available; mpv will pick the quality.")
+	} else {
+		sel = ui.GetStreamSelection(playerData, prefs.stream)
+		if sel.Audio == nil && sel.Muxed == nil {
+			return false, nil
+		}
+	}
+	video := sel.Video
+	if sel.Muxed != nil {
+		video = &sel.Muxed.VideoStream
+	}
+	if prefs.stream.Quality == "" && video != nil {
+		prefs.stream.Quality = video.Quality
+		if len(prefs.stream.Codecs) == 0 {
+			prefs.stream.Codecs = []string{video.Codec}
+		}
+		if prefs.stream.Fps == 0 {
+			prefs.stream.Fps = video.Fps
+		}
+	}
+	if prefs.stream.Language == "" && sel.Audio != nil {
+		prefs.stream.Language = sel.Audio.Language
+	}
+	opts := mpv.Options{
+		Subtitles:    ui.SelectSubtitles(playerData.Subtitles, prefs.subLangs, !prefs.noAutoSubs),
+		Segments:     <-segments,
+		SkipSegments: prefs.sbMode == "skip",
+		Dash:         prefs.dash,
+		AudioOnly:    prefs.stream.AudioOnly,
+		Start:        target.Start,
+		End:          target.End,
+		Resolver: func() (*models.PlayerData, error) {
+			return youtube.RefreshPlayerData(target.VideoId)
+		},
+	}
+	if err := mpv.Launch(playerData, sel, opts); err != nil {
+		return false, err
 	}
 	fmt.Print("\033[H\033[2J")
+	return true, nil
+}
+
+func fetchSegments(videoId string, prefs *preferences) <-chan []models.Segment {
+	ch := make(chan []models.Segment, 1)
+	if prefs.sbMode == "" {
+		ch <- nil
+		return ch
+	}
+	go func() {
+		segments, err := sponsorblock.GetSegments(prefs.sbApi, videoId, prefs.sbCats)
+		if err != nil {
+			segments = nil
+		}
+		ch <- segments
+	}()
+	return ch
+}
+
+func selectEntries(entries []models.PlaylistEntry, target youtube.Target, items string, shuffle, reverse bool) ([]models.PlaylistEntry, error) {
+	if items != "" {
+		indices, err := ui.ParseSelection(items, len(entries))
+		if err != nil {
+			return nil, err
+		}
+		selected := make([]models.PlaylistEntry, len(indices))
+		for i, idx := range indices {
+			selected[i] = entries[idx]
+		}
+		entries = selected
+	} else if target.VideoId != "" {
+		if idx := slices.IndexFunc(entries, func(e models.PlaylistEntry) bool { return e.VideoId == target.VideoId }); idx > 0 {
+			entries = entries[idx:]
+		}
+	} else if target.Index > 1 && target.Index <= len(entries) {
+		entries = entries[target.Index-1:]
+	}
+	if reverse {
+		entries = slices.Clone(entries)
+		slices.Reverse(entries)
+	}
+	if shuffle {
+		entries = slices.Clone(entries)
+		rand.Shuffle(len(entries), func(i, j int) {
+			entries[i], entries[j] = entries[j], entries[i]
+		})
+	}
+	return entries, nil
+}
+
+func playEntries(entries []models.PlaylistEntry, prefs *preferences, start youtube.Target) error {
+	items := make([]queueItem, len(entries))
+	for i, e := range entries {
+		items[i] = queueItem{target: entryTarget(e, start), title: e.Title}
+	}
+	return playQueue(items, nil, prefs)
+}
+
+func entryTarget(e models.PlaylistEntry, parent youtube.Target) youtube.Target {
+	if e.VideoId == parent.VideoId {
+		return parent
+	}
+	return youtube.Target{
+		Kind:       youtube.TargetPlaylistVideo,
+		VideoId:    e.VideoId,
+		PlaylistId: parent.PlaylistId,
+		Music:      parent.Music,
+	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"mpy-yt/internal/models"
+	"mpy-yt/internal/youtube"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestSelectEntries(t *testing.T) {
+	entries := []models.PlaylistEntry{
+		{VideoId: "aaaaaaaaaaa"},
+		{VideoId: "bbbbbbbbbbb"},
+		{VideoId: "ccccccccccc"},
+		{VideoId: "ddddddddddd"},
+	}
+	tests := []struct {
+		name    string
+		target  youtube.Target
+		items   string
+		reverse bool
+		want    []string
+		err     bool
+	}{
+		{"all", youtube.Target{}, "", false, []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc", "ddddddddddd"}, false},
+		{"from video", youtube.Target{VideoId: "ccccccccccc"}, "", false, []string{"ccccccccccc", "ddddddddddd"}, false},
+		{"unknown video", youtube.Target{VideoId: "zzzzzzzzzzz"}, "", false, []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc", "ddddddddddd"}, false},
+		{"from index", youtube.Target{Index: 2}, "", false, []string{"bbbbbbbbbbb", "ccccccccccc", "ddddddddddd"}, false},
+		{"index out of range", youtube.Target{Index: 9}, "", false, []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc", "ddddddddddd"}, false},
+		{"items beat video", youtube.Target{VideoId: "ccccccccccc"}, "1,2", false, []string{"aaaaaaaaaaa", "bbbbbbbbbbb"}, false},
+		{"items range", youtube.Target{}, "2-", false, []string{"bbbbbbbbbbb", "ccccccccccc", "ddddddddddd"}, false},
+		{"reverse", youtube.Target{}, "1-3", true, []string{"ccccccccccc", "bbbbbbbbbbb", "aaaaaaaaaaa"}, false},
+		{"invalid items", youtube.Target{}, "9", false, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := selectEntries(entries, tt.target, tt.items, false, tt.reverse)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		ids := make([]string, len(got))
+		for i, e := range got {
+			ids[i] = e.VideoId
+		}
+		if !tt.err && !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+	if entries[0].VideoId != "aaaaaaaaaaa" || entries[3].VideoId != "ddddddddddd" {
+		t.Error("selectEntries modified the input slice")
+	}
+
+	shuffled, err := selectEntries(entries, youtube.Target{}, "", true, false)
+	if err != nil || len(shuffled) != len(entries) {
+		t.Fatalf("shuffle returned %d entries, %v", len(shuffled), err)
+	}
+}
+
+func TestFetchSegmentsIgnoresErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	prefs := &preferences{sbMode: "skip", sbApi: srv.URL, sbCats: []string{"sponsor"}}
+	if segments := <-fetchSegments("dQw4w9WgXcQ", prefs); segments != nil {
+		t.Errorf("fetchSegments = %v, want nil after a failed request", segments)
+	}
+}
+
+func TestBuildQueueOrder(t *testing.T) {
+	targets := []youtube.Target{
+		{Kind: youtube.TargetVideo, VideoId: "aaaaaaaaaaa"},
+		{Kind: youtube.TargetVideo, VideoId: "bbbbbbbbbbb"},
+		{Kind: youtube.TargetVideo, VideoId: "ccccccccccc"},
+	}
+	ids := func(items []queueItem) []string {
+		var ids []string
+		for _, it := range items {
+			ids = append(ids, it.target.VideoId)
+		}
+		return ids
+	}
+	items, _ := buildQueue(targets, false, false)
+	if got := ids(items); !slices.Equal(got, []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc"}) {
+		t.Errorf("queue = %v", got)
+	}
+	items, _ = buildQueue(targets, false, true)
+	if got := ids(items); !slices.Equal(got, []string{"ccccccccccc", "bbbbbbbbbbb", "aaaaaaaaaaa"}) {
+		t.Errorf("reversed queue = %v", got)
+	}
+	items, _ = buildQueue(targets, true, false)
+	if got := ids(items); len(got) != 3 || !slices.Contains(got, "bbbbbbbbbbb") {
+		t.Errorf("shuffled queue = %v", got)
+	}
+}
+
+func TestCheckQueueFlags(t *testing.T) {
+	if err := checkQueueFlags("", "", ""); err != nil {
+		t.Errorf("no flags: %v", err)
+	}
+	for _, flags := range [][3]string{{"1-3", "", ""}, {"", "1:00", ""}, {"", "", "2:00"}} {
+		if err := checkQueueFlags(flags[0], flags[1], flags[2]); err == nil {
+			t.Errorf("checkQueueFlags%q accepted a single-target flag", flags)
+		}
+	}
+}
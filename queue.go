@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"mpy-yt/internal/youtube"
+	"os"
+	"slices"
+)
+
+type queueItem struct {
+	target youtube.Target
+	title  string
+}
+
+type queueFailure struct {
+	name string
+	err  error
+}
+
+func checkQueueFlags(items, start, end string) error {
+	for _, f := range [...]struct{ name, value string }{
+		{"--playlist-items", items},
+		{"--start", start},
+		{"--end", end},
+	} {
+		if f.value != "" {
+			return fmt.Errorf("%s only works with a single identifier", f.name)
+		}
+	}
+	return nil
+}
+
+func buildQueue(targets []youtube.Target, shuffle, reverse bool) ([]queueItem, []queueFailure) {
+	var items []queueItem
+	var failures []queueFailure
+	for _, t := range targets {
+		if t.Kind == youtube.TargetAlbum {
+			album, err := youtube.ResolveAlbum(t.AlbumId)
+			if err != nil {
+				failures = append(failures, queueFailure{name: t.AlbumId, err: err})
+				continue
+			}
+			t = album
+		}
+		switch t.Kind {
+		case youtube.TargetPlaylist, youtube.TargetPlaylistVideo:
+			playlist, err := youtube.GetPlaylist(t.PlaylistId, t.VideoId)
+			if err != nil && t.Kind == youtube.TargetPlaylistVideo {
+				items = append(items, queueItem{target: t})
+				continue
+			}
+			if err != nil {
+				failures = append(failures, queueFailure{name: t.PlaylistId, err: err})
+				continue
+			}
+			entries, _ := selectEntries(playlist.Entries, t, "", false, false)
+			for _, e := range entries {
+				items = append(items, queueItem{target: entryTarget(e, t), title: e.Title})
+			}
+		case youtube.TargetChannel:
+			failures = append(failures, queueFailure{name: t.Channel, err: errors.New("channels cannot be queued, open them on their own")})
+		default:
+			items = append(items, queueItem{target: t})
+		}
+	}
+	if reverse {
+		slices.Reverse(items)
+	}
+	if shuffle {
+		rand.Shuffle(len(items), func(i, j int) {
+			items[i], items[j] = items[j], items[i]
+		})
+	}
+	return items, failures
+}
+
+func playQueue(items []queueItem, failures []queueFailure, prefs *preferences) error {
+	pending := make([]chan resolved, len(items))
+	resolveAhead := func(i int) {
+		if i >= len(items) || pending[i] != nil {
+			return
+		}
+		ch := make(chan resolved, 1)
+		pending[i] = ch
+		go func() {
+			ch <- resolve(items[i].target, prefs)
+		}()
+	}
+
+	resolveAhead(0)
+	for i, it := range items {
+		r := <-pending[i]
+		resolveAhead(i + 1)
+		name := it.title
+		if name == "" && r.data != nil {
+			name = r.data.Title
+		}
+		if name == "" {
+			name = itemName(it.target)
+		}
+		fmt.Printf("[%d/%d] %s\n", i+1, len(items), name)
+		ok, err := playResolved(r, prefs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", itemName(it.target), err)
+			failures = append(failures, queueFailure{name: name, err: err})
+			continue
+		}
+		if !ok {
+			break
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	if len(items)+len(failures) > 1 {
+		fmt.Fprintf(os.Stderr, "\n%d item(s) failed:\n", len(failures))
+		for _, f := range failures {
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", f.name, f.err)
+		}
+	}
+	return failures[0].err
+}
+
+func itemName(t youtube.Target) string {
+	if t.VideoId != "" {
+		return t.VideoId
+	}
+	return t.ClipId
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mpy-yt/internal/models"
+	"mpy-yt/internal/ui"
+	"mpy-yt/internal/youtube"
+	"os"
+)
+
+func runSearch(query string, filter youtube.SearchFilter, prefs *preferences) error {
+	if query == "" {
+		return errors.New("no search query provided")
+	}
+	page, err := youtube.Search(query, filter)
+	if err != nil {
+		return err
+	}
+	return browse(page, prefs)
+}
+
+func runChannel(ref, tab string, prefs *preferences) error {
+	page, err := youtube.BrowseChannel(ref, tab)
+	if err != nil {
+		return err
+	}
+	return browse(page, prefs)
+}
+
+func browse(page *youtube.Page, prefs *preferences) error {
+	items := page.Items
+	shown := 0
+	if page.Title != "" {
+		fmt.Printf("%s\n\n", page.Title)
+	}
+	for {
+		selection, more := ui.PickItems(items, shown, page.HasMore())
+		if more {
+			next, err := page.Next()
+			if err != nil {
+				return err
+			}
+			shown, page = len(items), next
+			items = append(items, page.Items...)
+			continue
+		}
+		if len(selection) == 0 {
+			return nil
+		}
+		if it := items[selection[0]]; len(selection) == 1 && it.Kind == models.ItemChannel {
+			fmt.Print("\033[H\033[2J")
+			return runChannel(it.Id, "videos", prefs)
+		}
+		entries := make([]models.PlaylistEntry, 0, len(selection))
+		for _, idx := range selection {
+			it := items[idx]
+			switch it.Kind {
+			case models.ItemVideo:
+				entries = append(entries, models.PlaylistEntry{VideoId: it.Id, Title: it.Title, Channel: it.Channel})
+			case models.ItemPlaylist:
+				playlist, err := youtube.GetPlaylist(it.Id, "")
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %s: %v\n", it.Id, err)
+					continue
+				}
+				entries = append(entries, playlist.Entries...)
+			case models.ItemChannel:
+				fmt.Fprintf(os.Stderr, "Skipping channel '%s'; select it on its own to browse it.\n", it.Title)
+			}
+		}
+		fmt.Print("\033[H\033[2J")
+		return playEntries(entries, prefs, youtube.Target{})
+	}
+}